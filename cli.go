@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"montecarlo/equity"
+)
+
+// Settings passed on the command line instead of the interactive prompts
+type cliOptions struct {
+	equity.Config
+	// Either text or json
+	Output string
+	// Stops the simulation after this long, 0 to let it finish
+	Timeout time.Duration
+	// Keeps a progress line updated on the standard error
+	ShowProgress bool
+}
+
+// Tells you if the file is an interactive terminal rather than a pipe or a regular file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Most games played by default while waiting for the equities to reach the target precision
+const defaultPrecisionIterations = 10000000
+
+// A flag which can be repeated, collecting every value
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Reads the command line flags, e.g. --hand AhKh --hand QsQd --board 7s8s2h --iterations 1e6 --workers 8
+// Errors and the usage are printed to output.
+func parseFlags(args []string, output io.Writer) (cliOptions, error) {
+	var options cliOptions
+	var hands stringList
+	fs := flag.NewFlagSet("montecarlo", flag.ContinueOnError)
+	fs.SetOutput(output)
+	game := fs.String("game", "holdem", "the poker game, holdem, omaha (plo), omaha5 (plo5), shortdeck (6+), stud, razz, "+
+		"27 (deuce to seven single draw) or 27-triple-draw")
+	ranking := fs.String("ranking", "", "the hand categories from the strongest to the weakest, comma separated, "+
+		"e.g. \"straight flush, poker, flush, full house, straight, trips, two pairs, one pair, high card\". "+
+		"The order of the game when empty")
+	fs.BoolVar(&options.HiLo, "hilo", false, "split every pot between the best high and the best eight or better low")
+	fs.BoolVar(&options.Streets, "streets", false, "report who holds the best hand after the flop and the turn "+
+		"and how often that lead holds up")
+	fs.BoolVar(&options.StreetEquity, "street-equity", false, "also work out every players equity after the flop and the turn "+
+		"by playing out every board left, which makes simulating before the flop a lot slower")
+	fs.Var(&hands, "hand", "a players hole cards (AhKh, or AhKhQsJs in omaha), a holdem range (TT+, AKs:0.5), "+
+		"the known stud downcards and upcards (AhKh/Qs, or /9c) or the cards kept for the first draw in 27, repeat for every player")
+	fs.StringVar(&options.Board, "board", "", "community cards on the table, e.g. 7s8s2h")
+	iterations := fs.Float64("iterations", 100000, "number of games to simulate, e.g. 1e6")
+	fs.IntVar(&options.Workers, "workers", runtime.NumCPU(), "number of goroutines to use")
+	fs.StringVar(&options.Dead, "dead", "", "cards out of play, like folded or burned cards or folded stud upcards, e.g. 2c9d")
+	fs.StringVar(&options.Output, "output", "text", "result format, text or json")
+	fs.BoolVar(&options.ShowProgress, "progress", isTerminal(os.Stderr),
+		"show the games played, the speed and the current equities while simulating, on by default in a terminal")
+	fs.DurationVar(&options.Timeout, "timeout", 0, "stop simulating after this long and report the games played so far, e.g. 30s")
+	fs.Float64Var(&options.Precision, "precision", 0,
+		"keep simulating until every equity is known to within this margin at 95% confidence, e.g. 0.001 for ±0.1%. "+
+			"--iterations is then the most games to play")
+	fs.Int64Var(&options.Seed, "seed", 0, "seed for the random number generators, runs with the same seed, "+
+		"iterations and workers give the same results")
+	if err := fs.Parse(args); err != nil {
+		return options, err
+	}
+
+	// Report invalid values the same way the flag package reports unknown flags
+	invalid := func(format string, a ...interface{}) (cliOptions, error) {
+		err := fmt.Errorf(format, a...)
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return options, err
+	}
+	if fs.NArg() > 0 {
+		return invalid("unexpected arguments: %v", fs.Args())
+	}
+	if len(hands) == 0 {
+		return invalid("at least one --hand is required")
+	}
+	if *iterations < 1 || *iterations > math.MaxInt32 || *iterations != math.Trunc(*iterations) {
+		return invalid("--iterations must be a whole number between 1 and %v", math.MaxInt32)
+	}
+	variant, err := equity.ParseVariant(*game)
+	if err != nil {
+		return invalid("--game: %v", err)
+	}
+	if *ranking != "" {
+		if options.Ranking, err = equity.ParseHandRanking(*ranking); err != nil {
+			return invalid("--ranking: %v", err)
+		}
+	}
+	if options.Workers < 1 {
+		return invalid("--workers must be at least 1")
+	}
+	if options.Output != "text" && options.Output != "json" {
+		return invalid("--output must be text or json")
+	}
+	if options.Timeout < 0 {
+		return invalid("--timeout can't be negative")
+	}
+	if options.Precision < 0 || options.Precision >= 1 {
+		return invalid("--precision must be between 0 and 1")
+	}
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	if options.Precision > 0 && !given["iterations"] {
+		*iterations = defaultPrecisionIterations
+	}
+	if !given["seed"] {
+		options.Seed = time.Now().UnixNano()
+	}
+	options.Variant = variant
+	options.Hands = hands
+	options.Iterations = int(*iterations)
+	return options, nil
+}
@@ -0,0 +1,109 @@
+package equity
+
+import (
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+// A set of cards where every card is a single bit.
+// Each suit takes 16 bits and a card sits on the bit of its face value (2 to 14, the ace plays high).
+type CardMask uint64
+
+const suitBits = 16
+
+// Gets the bit of a single card
+func (c Card) mask() CardMask {
+	return CardMask(1) << (suitIndex(c.Suit)*suitBits + highValue(c.Number))
+}
+
+// Builds a set out of single cards
+func MaskOf(cards ...Card) CardMask {
+	var m CardMask
+	for _, c := range cards {
+		m |= c.mask()
+	}
+	return m
+}
+
+// Number of cards in the set
+func (m CardMask) Count() int {
+	return bits.OnesCount64(uint64(m))
+}
+
+func (m CardMask) Contains(c Card) bool {
+	return m&c.mask() != 0
+}
+
+// Face values of one suit in the set (bit n set = value n is present)
+func (m CardMask) suitValues(suit int) uint16 {
+	return uint16(m >> (suit * suitBits))
+}
+
+// Translates a bit position back to a card
+func cardFromBit(bit int) Card {
+	number := int8(bit % suitBits)
+	if number == aceHigh {
+		number = 1
+	}
+	return Card{number, getAllSuits()[bit/suitBits]}
+}
+
+// Lists the cards in the set, ordered by suit and face value
+func (m CardMask) Cards() []Card {
+	var cards []Card
+	for m != 0 {
+		cards = append(cards, cardFromBit(bits.TrailingZeros64(uint64(m))))
+		m &= m - 1
+	}
+	return cards
+}
+
+// Lists every card of the set as its own single bit mask
+func (m CardMask) singles() []CardMask {
+	var singles []CardMask
+	for m != 0 {
+		singles = append(singles, m&-m)
+		m &= m - 1
+	}
+	return singles
+}
+
+// Picks the nth card of the set, counting from the lowest bit
+func (m CardMask) nth(n int) CardMask {
+	// Skip whole suits first
+	for suit := 0; suit < 4; suit++ {
+		lane := m & (CardMask(0xffff) << (suit * suitBits))
+		inLane := lane.Count()
+		if n >= inLane {
+			n -= inLane
+			continue
+		}
+		for ; n > 0; n-- {
+			lane &= lane - 1
+		}
+		return lane & -lane
+	}
+	return 0
+}
+
+// Formats the cards from the highest face value down, like AhKh
+func (m CardMask) String() string {
+	cards := m.Cards()
+	sort.SliceStable(cards, func(i, j int) bool {
+		return highValue(cards[i].Number) > highValue(cards[j].Number)
+	})
+	var text strings.Builder
+	for _, card := range cards {
+		text.WriteString(card.String())
+	}
+	return text.String()
+}
+
+// Formats the hole cards like AhKh, or the downcards and the upcards of a stud hand like AhKh/Qs
+func (h Hand) String() string {
+	if h.Up != 0 {
+		return (h.Cards &^ h.Up).String() + "/" + h.Up.String()
+	}
+	return h.Cards.String()
+}
@@ -0,0 +1,54 @@
+package equity
+
+import "math/rand"
+
+// Cards every player holds in a draw game
+const drawCards = 5
+
+// The worst low a triple draw player stands pat with, a nine low
+const patHighCard = 9
+
+// The highest card a triple draw player keeps to draw to
+const keepHighCard = 7
+
+// Picks the cards a triple draw player keeps for the next draw.
+// A nine low or better stands pat, otherwise the player keeps one card of every value from the deuce to the seven.
+// When those are all five cards but make a straight or a flush, the highest of them goes too.
+func keepForDraw(hand CardMask) CardMask {
+	values := hand.suitValues(0) | hand.suitValues(1) | hand.suitValues(2) | hand.suitValues(3)
+	if RankCategory(EvaluateDeuceToSeven(hand)) == categoryHighCard && highestValue(values) <= patHighCard {
+		return hand
+	}
+	var kept CardMask
+	var keptValues uint16
+	for suit := 0; suit < 4; suit++ {
+		// Values from the deuce to the seven which aren't kept in another suit yet
+		suitValues := hand.suitValues(suit) & (1<<(keepHighCard+1) - 1<<2) &^ keptValues
+		kept |= CardMask(suitValues) << (suit * suitBits)
+		keptValues |= suitValues
+	}
+	if kept.Count() == drawCards {
+		highest := highestValue(keptValues)
+		for suit := 0; suit < 4; suit++ {
+			kept &^= CardMask(1) << (suit*suitBits + highest)
+		}
+	}
+	return kept
+}
+
+// Plays the draws after the first one: every player keeps the cards keepForDraw picks and draws back to five.
+// The discards are shuffled back into the deck once it runs out, as at the table.
+func playLaterDraws(hands []Hand, draws int, deck *CardMask, rng *rand.Rand) {
+	var discards CardMask
+	for draw := 1; draw < draws; draw++ {
+		for playerIndex, hand := range hands {
+			kept := keepForDraw(hand.Cards)
+			if deck.Count() < drawCards-kept.Count() {
+				*deck |= discards
+				discards = 0
+			}
+			hands[playerIndex].Cards = kept | getRandomCardsFromDeck(deck, drawCards-kept.Count(), rng)
+			discards |= hand.Cards &^ kept
+		}
+	}
+}
@@ -0,0 +1,784 @@
+// Package equity works out how often every player wins a poker hand, by playing
+// the remaining cards either one by one or as a Monte Carlo simulation.
+// It plays hold'em, Omaha (PLO4 and PLO5), short deck, seven card stud, razz and 2-7 lowball
+// single and triple draw, with hi/lo split pots in the games played for the high hand.
+package equity
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+var debugMode bool = false
+
+type char byte
+
+type Board struct {
+	Cards CardMask
+}
+
+// Tells you the status of the game
+func (t *Board) status() (int, error) {
+	switch t.Cards.Count() {
+	case 0: // pre-flop
+		return 0, nil
+	case 3: //flop
+		return 1, nil
+	case 4: // turn
+		return 2, nil
+	case 5: // river
+		return 3, nil
+	default:
+		return 0, fmt.Errorf("%w, got %v", ErrBoardSize, t.Cards.Count())
+	}
+}
+
+// The combination IDs of the hand categories, from the strongest to the weakest
+const (
+	categoryStraightFlush int8 = iota + 1
+	categoryPoker
+	categoryFullHouse
+	categoryFlush
+	categoryStraight
+	categoryTrips
+	categoryTwoPairs
+	categoryOnePair
+	categoryHighCard
+)
+
+type Card struct {
+	Number int8
+	Suit   char
+}
+
+type Hand struct {
+	Cards CardMask
+	// The cards everybody can see in stud, they're part of Cards too
+	Up CardMask
+}
+
+type Game struct {
+	Variant Variant
+	// Splits every pot between the best high and the best eight or better low
+	HiLo bool
+	// The order of the hand categories, the usual order when nil
+	Ranking HandRanking
+	// Notes who holds the best hand after the flop and the turn
+	Streets bool
+	// Works out every players equity after the flop and the turn by playing out every board left from there
+	StreetEquity bool
+	Table        Board
+	Hands        []Hand
+	// Players with a range get a hand dealt in every game, nil for players with a known hand
+	Ranges []*Range
+	// Cards known to be out of play, like folded or burned cards, which nobody can be dealt
+	Dead CardMask
+	Deck CardMask
+}
+
+func (s char) String() string {
+	return fmt.Sprintf("%c", s)
+}
+
+func getAllNumbers(doubleAce bool) []int8 {
+	cards := []int8{
+		1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13,
+	}
+	if doubleAce {
+		cards = append(cards, 14)
+	}
+	return cards
+}
+
+func getAllSuits() []char {
+	return []char{
+		'H', 'D', 'C', 'S',
+	}
+}
+
+// Creates a 52 cards deck
+func createDeck() CardMask {
+	var deck CardMask
+	for _, s := range getAllSuits() {
+		for _, n := range getAllNumbers(false) {
+			deck |= Card{n, s}.mask()
+		}
+	}
+	return deck
+}
+
+// Builds the 36 card deck without the 2 to 5
+func createShortDeck() CardMask {
+	deck := createDeck()
+	for _, s := range getAllSuits() {
+		for n := int8(2); n <= 5; n++ {
+			deck &^= Card{n, s}.mask()
+		}
+	}
+	return deck
+}
+
+// Takes the 2 player cards out of the deck
+func addHandToTable(hand Hand, deck *CardMask, hands *[]Hand) {
+	*hands = append(*hands, hand)
+	*deck &^= hand.Cards
+}
+
+func addCardToTable(card Card, deck *CardMask) {
+	*deck &^= card.mask()
+}
+
+// Extracts n amount of cards from the deck
+func getRandomCardsFromDeck(deck *CardMask, nr int, rng *rand.Rand) CardMask {
+	var cards CardMask
+	for i := 0; i < nr; i++ {
+		crd := deck.nth(rng.Intn(deck.Count()))
+		*deck &^= crd
+		cards |= crd
+	}
+	return cards
+}
+
+// Below this number of possible board completions we walk every board instead of sampling
+const exactEnumerationThreshold = 100000
+
+// Counts in how many ways nr cards can be drawn from a deck of deckSize cards
+func countCardCombinations(deckSize int, nr int) int {
+	if nr < 0 || nr > deckSize {
+		return 0
+	}
+	count := 1
+	for i := 1; i <= nr; i++ {
+		count = count * (deckSize - nr + i) / i
+	}
+	return count
+}
+
+// Calls fn with every possible set of nr cards from the deck
+func forEachCardCombination(deck CardMask, nr int, fn func(CardMask)) {
+	singles := deck.singles()
+	var pick func(start int, depth int, picked CardMask)
+	pick = func(start int, depth int, picked CardMask) {
+		if depth == nr {
+			fn(picked)
+			return
+		}
+		for i := start; i <= len(singles)-(nr-depth); i++ {
+			pick(i+1, depth+1, picked|singles[i])
+		}
+	}
+	pick(0, 0, 0)
+}
+
+// Gets a human-readable combination name
+func CombinationName(input int8) string {
+	mapping := map[int8]string{
+		categoryStraightFlush: "Straight Flush",
+		categoryPoker:         "Poker",
+		categoryFullHouse:     "Full House",
+		categoryFlush:         "Flush",
+		categoryStraight:      "Straight",
+		categoryTrips:         "Trips",
+		categoryTwoPairs:      "Two Pairs",
+		categoryOnePair:       "One Pair",
+		categoryHighCard:      "High Card",
+	}
+	return mapping[input]
+}
+
+// Checks that no card is in the deck, on the table or in the players hands more than once
+func checkDeckHealth(deck CardMask, table CardMask, dead CardMask, hands []Hand) error {
+	seen := deck
+	for _, cards := range []CardMask{table, dead} {
+		if seen&cards != 0 {
+			return fmt.Errorf("%w: %v", ErrDuplicateCard, (seen & cards).Cards()[0])
+		}
+		seen |= cards
+	}
+	for _, hand := range hands {
+		if seen&hand.Cards != 0 {
+			return fmt.Errorf("%w: %v", ErrDuplicateCard, (seen & hand.Cards).Cards()[0])
+		}
+		seen |= hand.Cards
+	}
+	return nil
+}
+
+// Names the street a table status stands for
+func getStreetName(status int) string {
+	return []string{"preflop", "flop", "turn", "river"}[status]
+}
+
+// Tells you how many community cards we still need to pull from deck
+func communityCardsLeft(status int) int {
+	return [...]int{5, 2, 1, 0}[status]
+}
+
+// Registers a players hand rank and determines if it beats the previous best
+// All the players sharing the best hand end up in winners.
+// The ranks have to be in the ranking order of the game, see Game.evaluate.
+func registerPlayerHand(id int, rank uint32, bestRank *uint32, winners *[]int) {
+	if rank > *bestRank {
+		// clear win for the candidate
+		*bestRank = rank
+		*winners = append((*winners)[:0], id)
+	} else if rank == *bestRank {
+		// If there is a tie, the pot is shared with the previous best
+		*winners = append(*winners, id)
+	}
+}
+
+// Finds the players holding the best hand with the community cards dealt so far, reusing the leaders slice
+func findLeaders(game Game, communityCards CardMask, hands []Hand, leaders []int) []int {
+	var bestRank uint32
+	leaders = leaders[:0]
+	for playerIndex, hand := range hands {
+		registerPlayerHand(playerIndex, game.evaluate(communityCards, hand.Cards), &bestRank, &leaders)
+	}
+	return leaders
+}
+
+// Plays out every board which can still come from the deck and tallies who holds the best hand on each of them
+func playOutRunouts(game Game, communityCards CardMask, hands []Hand, deck CardMask) []runoutStats {
+	runouts := make([]runoutStats, len(hands))
+	var winners []int
+	forEachCardCombination(deck, game.Variant.boardCards()-communityCards.Count(), func(rest CardMask) {
+		winners = findLeaders(game, communityCards|rest, hands, winners)
+		for id := range runouts {
+			runouts[id].register(id, winners)
+		}
+	})
+	return runouts
+}
+
+func containsPlayer(ids []int, id int) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Finds the players holding the best qualifying low, nil when nobody has one. Reuses the winners slice.
+func findLowWinners(game Game, communityCards CardMask, hands []Hand, winners []int) []int {
+	var bestRank uint32
+	winners = winners[:0]
+	for playerIndex, hand := range hands {
+		if rank := game.evaluateLow(communityCards, hand.Cards); rank > 0 {
+			registerPlayerHand(playerIndex, rank, &bestRank, &winners)
+		}
+	}
+	if bestRank == 0 {
+		return nil
+	}
+	return winners
+}
+
+// Tracks how a single player did across all the simulated games
+type playerStats struct {
+	Wins int
+	Ties int
+	// SplitPots[k] counts the pots this player shared between k players
+	SplitPots []int
+	// How many times each hand class was dealt to a range player
+	SampledClasses map[string]int
+	// How the player did, keyed by the combination ID (as in the category constants) of their final hand
+	Categories map[int8]categoryStats
+	// How the player stood after the flop and the turn, keyed by the table status of the street
+	Streets map[int]streetStats
+	// How the player shared the pots of split pot games, nil unless the pots are split between high and low.
+	// Games where the player got nothing aren't counted.
+	HiLo map[hiLoShare]int
+	// Split pot games where the player took the whole pot
+	Scoops int
+}
+
+// How a players position after one street turned out at the river.
+// Being ahead or winning includes sharing the best hand with other players.
+type streetStats struct {
+	Ahead        int
+	AheadAndWon  int
+	BehindButWon int
+	// Every board played out from the street on, over all the games, when the street equity is worked out
+	Runouts runoutStats
+	// Games by the players equity after the street, in tenths, the last one includes 100%
+	Equities [streetEquityBuckets]int
+}
+
+// Number of parts the equity after a street is split into
+const streetEquityBuckets = 10
+
+// How a player did over the boards played out from one street on
+type runoutStats struct {
+	Runouts int
+	Wins    int
+	// Splits[k] counts the boards where the player shared the best hand between k players
+	Splits []int
+}
+
+// Counts a board played out from the street, the winners are the players holding the best hand on it
+func (r *runoutStats) register(id int, winners []int) {
+	r.Runouts++
+	if !containsPlayer(winners, id) {
+		return
+	}
+	if len(winners) == 1 {
+		r.Wins++
+		return
+	}
+	for len(r.Splits) <= len(winners) {
+		r.Splits = append(r.Splits, 0)
+	}
+	r.Splits[len(winners)]++
+}
+
+func (r *runoutStats) merge(other runoutStats) {
+	r.Runouts += other.Runouts
+	r.Wins += other.Wins
+	for len(r.Splits) < len(other.Splits) {
+		r.Splits = append(r.Splits, 0)
+	}
+	for ways, count := range other.Splits {
+		r.Splits[ways] += count
+	}
+}
+
+// Share of the pots the player takes over the boards played out
+func (r runoutStats) equity() float64 {
+	share := float64(r.Wins)
+	for ways, count := range r.Splits {
+		if count > 0 {
+			share += float64(count) / float64(ways)
+		}
+	}
+	return fraction(share, r.Runouts)
+}
+
+// The part of the equity range from 0 to 1 the equity falls in
+func equityBucket(equity float64) int {
+	bucket := int(equity * streetEquityBuckets)
+	if bucket >= streetEquityBuckets {
+		return streetEquityBuckets - 1
+	}
+	return bucket
+}
+
+// How a player did in the games they ended up with one hand category
+type categoryStats struct {
+	Games int
+	Wins  int
+	Ties  int
+}
+
+// What happened in a single simulated game.
+// The slices are buffers of the worker which played the game, they only hold until its next game.
+type gameResult struct {
+	// Nil when the game couldn't be dealt. In a split pot game, these are the players with the best high hand.
+	Winners []int
+	// Set when the pot is split between high and low
+	HiLo bool
+	// The players with the best qualifying low, nil when nobody made one
+	LowWinners []int
+	// Hand class dealt to each range player, empty for players with a known hand
+	Classes []string
+	// Set when the game couldn't be played, which only happens with a game that wasn't validated
+	Err error
+	// Combination ID (as in the category constants) of every players final hand
+	Categories []int8
+	// The players holding the best hand after each street, indexed by table status.
+	// Nil for the streets which were already on the table.
+	Leaders [][]int
+	// How every player did over all the boards left after each street, indexed by table status like Leaders.
+	// Nil unless the game works out the street equity.
+	StreetRunouts [][]runoutStats
+}
+
+// Credits the winners of a single game, a split pot gives each of them 1/k
+func registerGameResult(result gameResult, stats []playerStats) {
+	for id, class := range result.Classes {
+		if class == "" {
+			continue
+		}
+		if stats[id].SampledClasses == nil {
+			stats[id].SampledClasses = make(map[string]int)
+		}
+		stats[id].SampledClasses[class]++
+	}
+
+	winners := result.Winners
+	for _, id := range winners {
+		if len(winners) == 1 {
+			stats[id].Wins++
+			continue
+		}
+		stats[id].Ties++
+		for len(stats[id].SplitPots) <= len(winners) {
+			stats[id].SplitPots = append(stats[id].SplitPots, 0)
+		}
+		stats[id].SplitPots[len(winners)]++
+	}
+
+	if result.HiLo {
+		for id, share := range hiLoShares(result, len(stats)) {
+			if stats[id].HiLo == nil {
+				stats[id].HiLo = make(map[hiLoShare]int)
+			}
+			if share.High > 0 || share.Low > 0 {
+				stats[id].HiLo[share]++
+			}
+			if share.scoop() {
+				stats[id].Scoops++
+			}
+		}
+	}
+
+	for id, category := range result.Categories {
+		if stats[id].Categories == nil {
+			stats[id].Categories = make(map[int8]categoryStats)
+		}
+		categoryStats := stats[id].Categories[category]
+		categoryStats.Games++
+		if containsPlayer(winners, id) && len(winners) == 1 {
+			categoryStats.Wins++
+		} else if containsPlayer(winners, id) {
+			categoryStats.Ties++
+		}
+		stats[id].Categories[category] = categoryStats
+	}
+
+	for status, leaders := range result.Leaders {
+		if leaders == nil {
+			continue
+		}
+		for id := range stats {
+			if stats[id].Streets == nil {
+				stats[id].Streets = make(map[int]streetStats)
+			}
+			streetStats := stats[id].Streets[status]
+			ahead, won := containsPlayer(leaders, id), containsPlayer(winners, id)
+			if ahead {
+				streetStats.Ahead++
+			}
+			if ahead && won {
+				streetStats.AheadAndWon++
+			}
+			if !ahead && won {
+				streetStats.BehindButWon++
+			}
+			if result.StreetRunouts != nil && result.StreetRunouts[status] != nil {
+				runouts := result.StreetRunouts[status][id]
+				streetStats.Runouts.merge(runouts)
+				streetStats.Equities[equityBucket(runouts.equity())]++
+			}
+			stats[id].Streets[status] = streetStats
+		}
+	}
+}
+
+// Adds the tallies another worker collected for the same player
+func (p *playerStats) merge(other playerStats) {
+	p.Wins += other.Wins
+	p.Ties += other.Ties
+	for len(p.SplitPots) < len(other.SplitPots) {
+		p.SplitPots = append(p.SplitPots, 0)
+	}
+	for ways, count := range other.SplitPots {
+		p.SplitPots[ways] += count
+	}
+	for class, count := range other.SampledClasses {
+		if p.SampledClasses == nil {
+			p.SampledClasses = make(map[string]int)
+		}
+		p.SampledClasses[class] += count
+	}
+	for category, otherStats := range other.Categories {
+		if p.Categories == nil {
+			p.Categories = make(map[int8]categoryStats)
+		}
+		categoryStats := p.Categories[category]
+		categoryStats.Games += otherStats.Games
+		categoryStats.Wins += otherStats.Wins
+		categoryStats.Ties += otherStats.Ties
+		p.Categories[category] = categoryStats
+	}
+	for share, count := range other.HiLo {
+		if p.HiLo == nil {
+			p.HiLo = make(map[hiLoShare]int)
+		}
+		p.HiLo[share] += count
+	}
+	p.Scoops += other.Scoops
+	for status, otherStats := range other.Streets {
+		if p.Streets == nil {
+			p.Streets = make(map[int]streetStats)
+		}
+		streetStats := p.Streets[status]
+		streetStats.Ahead += otherStats.Ahead
+		streetStats.AheadAndWon += otherStats.AheadAndWon
+		streetStats.BehindButWon += otherStats.BehindButWon
+		streetStats.Runouts.merge(otherStats.Runouts)
+		for bucket, count := range otherStats.Equities {
+			streetStats.Equities[bucket] += count
+		}
+		p.Streets[status] = streetStats
+	}
+}
+
+func (p playerStats) winProbability(games int) float64 {
+	return fraction(float64(p.Wins), games)
+}
+
+func (p playerStats) tieProbability(games int) float64 {
+	return fraction(float64(p.Ties), games)
+}
+
+// Mean of the squared share of the pot per game, needed for the variance of the equity
+func (p playerStats) equitySquared(games int) float64 {
+	if p.HiLo != nil {
+		return p.averageHiLoShare(games, func(share hiLoShare) float64 {
+			return share.pot() * share.pot()
+		})
+	}
+	share := float64(p.Wins)
+	for ways, count := range p.SplitPots {
+		if count > 0 {
+			share += float64(count) / float64(ways*ways)
+		}
+	}
+	return fraction(share, games)
+}
+
+// Half width of the 95% confidence interval around the players equity
+func (p playerStats) confidenceInterval(games int) float64 {
+	if games < 2 {
+		return 1
+	}
+	equity := p.equity(games)
+	variance := (p.equitySquared(games) - equity*equity) * float64(games) / float64(games-1)
+	if variance < 0 {
+		variance = 0
+	}
+	return 1.96 * math.Sqrt(variance/float64(games))
+}
+
+// Lists the sampled hand classes, the most frequent first
+func (p playerStats) sortedClasses() []string {
+	var classes []string
+	for class := range p.SampledClasses {
+		classes = append(classes, class)
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		ci, cj := p.SampledClasses[classes[i]], p.SampledClasses[classes[j]]
+		if ci != cj {
+			return ci > cj
+		}
+		return classes[i] < classes[j]
+	})
+	return classes
+}
+
+// Share of all the pots this player is expected to take
+func (p playerStats) equity(games int) float64 {
+	if p.HiLo != nil {
+		return p.averageHiLoShare(games, hiLoShare.pot)
+	}
+	share := float64(p.Wins)
+	for ways, count := range p.SplitPots {
+		if count > 0 {
+			share += float64(count) / float64(ways)
+		}
+	}
+	return fraction(share, games)
+}
+
+// Slices a worker reuses from one game to the next, every game is tallied before the next one is played
+type gameBuffers struct {
+	hands      []Hand
+	dealt      []RangeCombo
+	classes    []string
+	categories []int8
+	winners    []int
+	lowWinners []int
+	leaders    [][]int
+}
+
+func newGameBuffers(players int) *gameBuffers {
+	return &gameBuffers{
+		hands:      make([]Hand, players),
+		dealt:      make([]RangeCombo, players),
+		classes:    make([]string, players),
+		categories: make([]int8, players),
+		leaders:    make([][]int, 3),
+	}
+}
+
+// Plays a single game of the scenario, dealing the range hands and the rest of the board
+func playGame(work Game, rng *rand.Rand, buffers *gameBuffers) gameResult {
+	communityCards := work.Table.Cards
+	deck := work.Deck
+	hands := work.Hands
+	result := gameResult{}
+	tableStatus, err := work.Table.status()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if len(work.Ranges) > 0 || !work.Variant.HasBoard() {
+		// The dealt hands go into the buffer, the scenario keeps the known cards
+		hands = append(buffers.hands[:0], work.Hands...)
+	}
+	if len(work.Ranges) > 0 {
+		dealt := buffers.dealt
+		if !dealRangeHands(work.Ranges, dealt, &deck, rng) {
+			// The ranges can't be dealt around the known cards, so this game doesn't count
+			return result
+		}
+		result.Classes = buffers.classes
+		for playerIndex, playerRange := range work.Ranges {
+			if playerRange != nil {
+				hands[playerIndex] = dealt[playerIndex].Hand
+				result.Classes[playerIndex] = dealt[playerIndex].Class
+			}
+		}
+	}
+	if !work.Variant.HasBoard() {
+		dealPrivateCards(hands, work.Variant.holeCards(), &deck, rng)
+		playLaterDraws(hands, work.Variant.draws(), &deck, rng)
+	}
+	// Deal the board street by street, noting who leads after every street before the river when asked to
+	for status := tableStatus; status < 3 && work.Variant.boardCards() > 0; status++ {
+		communityCards |= getRandomCardsFromDeck(&deck, communityCardsLeft(status)-communityCardsLeft(status+1), rng)
+		if work.Streets && status+1 < 3 {
+			// The streets already on the table stay nil, the scenario is the same in every game of a worker
+			result.Leaders = buffers.leaders
+			result.Leaders[status+1] = findLeaders(work, communityCards, hands, result.Leaders[status+1])
+			if work.StreetEquity && !work.HiLo {
+				if result.StreetRunouts == nil {
+					result.StreetRunouts = make([][]runoutStats, 3)
+				}
+				result.StreetRunouts[status+1] = playOutRunouts(work, communityCards, hands, deck)
+			}
+		}
+	}
+	var bestRank uint32
+	weHaveAWinner := buffers.winners[:0]
+	result.Categories = buffers.categories
+	if err := checkDeckHealth(deck, communityCards, work.Dead, hands); err != nil {
+		result.Err = err
+		return result
+	}
+
+	// Calculate the best combination each player holds
+	for playerIndex, hand := range hands {
+		if err := work.Variant.checkHand(hand); err != nil {
+			result.Err = &ValidationError{playerField(playerIndex), err}
+			return result
+		}
+		if communityCards.Count() != work.Variant.boardCards() {
+			result.Err = &ValidationError{"board", fmt.Errorf("%w, got %v", ErrBoardSize, communityCards.Count())}
+			return result
+		}
+		rank := work.evaluate(communityCards, hand.Cards)
+		result.Categories[playerIndex] = RankCategory(rank)
+		if debugMode {
+			fmt.Printf("Player %v has: %v with %v\n", playerIndex, CombinationName(RankCategory(rank)),
+				(communityCards | hand.Cards).Cards())
+		}
+		registerPlayerHand(playerIndex, rank, &bestRank, &weHaveAWinner)
+	}
+
+	if debugMode {
+		if len(weHaveAWinner) == 1 {
+			fmt.Printf("Player %v wins\n\n", weHaveAWinner[0])
+		} else {
+			fmt.Printf("Players %v split the pot\n\n", weHaveAWinner)
+		}
+	}
+	result.Winners = weHaveAWinner
+	buffers.winners = weHaveAWinner
+	if work.HiLo {
+		result.HiLo = true
+		result.LowWinners = findLowWinners(work, communityCards, hands, buffers.lowWinners)
+		if result.LowWinners != nil {
+			buffers.lowWinners = result.LowWinners
+		}
+	}
+	return result
+}
+
+// Games a worker plays before handing its tallies over
+const workerChunkSize = 1000
+
+// Keeps the tallies of a single worker, handing them over every chunk of games
+type tallySender struct {
+	ctx     context.Context
+	tallies chan<- simulationStats
+	local   simulationStats
+}
+
+func newTallySender(ctx context.Context, players int, tallies chan<- simulationStats) *tallySender {
+	return &tallySender{ctx, tallies, newSimulationStats(players)}
+}
+
+// Tallies a game, returns false once the context is done and the worker should stop
+func (s *tallySender) add(result gameResult) bool {
+	s.local.register(result)
+	select {
+	case <-s.ctx.Done():
+		return false
+	default:
+	}
+	if s.local.Dealt >= workerChunkSize {
+		s.flush()
+	}
+	return true
+}
+
+// Hands over the tallies collected since the last time.
+// The collector keeps taking tallies until every worker is done, even after the context is done.
+func (s *tallySender) flush() {
+	if s.local.Dealt == 0 {
+		return
+	}
+	s.tallies <- s.local
+	s.local = newSimulationStats(len(s.local.Stats))
+}
+
+// Plays quota random games of the scenario, keeping its own tallies.
+// Every worker draws from its own random source, so the workers don't share a lock and a seeded run can be repeated.
+func casinoWorker(ctx context.Context, work Game, quota int, rng *rand.Rand, tallies chan<- simulationStats) {
+	if debugMode {
+		fmt.Println("Starting worker")
+		defer fmt.Println("Worker done")
+	}
+	sender := newTallySender(ctx, len(work.Hands), tallies)
+	buffers := newGameBuffers(len(work.Hands))
+	for i := 0; i < quota; i++ {
+		if !sender.add(playGame(work, rng, buffers)) {
+			break
+		}
+	}
+	// Even a stopped worker hands over the games it played
+	sender.flush()
+}
+
+// Plays the boards completing the scenario whose index falls to this worker, out of all the workers
+func enumerationWorker(ctx context.Context, work Game, boards []CardMask, worker int, workers int, rng *rand.Rand,
+	tallies chan<- simulationStats) {
+	sender := newTallySender(ctx, len(work.Hands), tallies)
+	buffers := newGameBuffers(len(work.Hands))
+	for i := worker; i < len(boards); i += workers {
+		// Each game gets a complete board, so no random cards are pulled
+		board := work
+		board.Table = Board{work.Table.Cards | boards[i]}
+		board.Deck = work.Deck &^ boards[i]
+		if !sender.add(playGame(board, rng, buffers)) {
+			break
+		}
+	}
+	sender.flush()
+}
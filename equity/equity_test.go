@@ -0,0 +1,1906 @@
+package equity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// use go test -cover to get code coverage
+
+// Random source shared by the tests, seeded so a failing run can be repeated
+var rng = rand.New(rand.NewSource(1))
+
+func TestCommunityCardStatus(t *testing.T) {
+	cards := Board{MaskOf(
+		Card{1, 'C'},
+		Card{1, 'D'},
+	)}
+	if _, err := cards.status(); !errors.Is(err, ErrBoardSize) {
+		t.Errorf("Expected a board size error, got %v", err)
+	}
+
+	cards = Board{MaskOf(
+		Card{1, 'C'},
+		Card{1, 'D'},
+		Card{1, 'H'},
+	)}
+
+	if status, err := cards.status(); status != 1 || err != nil {
+		t.Error("Community Card status should be 1, (flop)")
+	}
+}
+
+func TestIfThereIsAnExtraAceInDeck(t *testing.T) {
+	expected := []int8{
+		1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14,
+	}
+	if !EqualInt8Slice(getAllNumbers(true), expected) {
+		t.Error("Ace is missing from the deck")
+	}
+}
+
+func TestDeckHealth(t *testing.T) {
+	deck := createDeck()
+	if err := checkDeckHealth(deck, 0, 0, nil); err != nil {
+		t.Error(err)
+	}
+
+	if deck.Count() != 52 {
+		t.Error("Deck is not healthy")
+	}
+
+	hands := []Hand{{Cards: MaskOf(Card{1, 'H'}, Card{2, 'H'})}}
+	if err := checkDeckHealth(deck, 0, 0, hands); !errors.Is(err, ErrDuplicateCard) {
+		t.Errorf("Expected a duplicate card, got %v", err)
+	}
+
+	addHandToTable(hands[0], &deck, &hands)
+	table := MaskOf(Card{2, 'H'}, Card{3, 'H'}, Card{4, 'H'})
+	if err := checkDeckHealth(deck, table, 0, hands); !errors.Is(err, ErrDuplicateCard) {
+		t.Errorf("Expected a duplicate card, got %v", err)
+	}
+
+	// Dead cards can't be in the deck or in anyones hand
+	dead := Card{2, 'H'}.mask()
+	if err := checkDeckHealth(deck, 0, dead, hands); !errors.Is(err, ErrDuplicateCard) {
+		t.Errorf("Expected a duplicate card, got %v", err)
+	}
+	deck = createDeck()
+	deck &^= dead
+	if err := checkDeckHealth(deck, 0, dead, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRegisterPlayerHand(t *testing.T) {
+	poker := Evaluate(MaskOf([]Card{{1, 'H'}, {1, 'D'}, {1, 'C'}, {1, 'S'}, {2, 'H'}}...))
+	worsePoker := Evaluate(MaskOf([]Card{{3, 'H'}, {3, 'D'}, {3, 'C'}, {3, 'S'}, {2, 'H'}}...))
+	winner := []int{1}
+	best := worsePoker
+	registerPlayerHand(2, poker, &best, &winner)
+	if !EqualIntSlice(winner, []int{2}) || best != poker {
+		t.Error("Player hand registered incorrectly")
+	}
+
+	twoPairs := Evaluate(MaskOf([]Card{{1, 'H'}, {1, 'D'}, {3, 'C'}, {3, 'S'}, {2, 'H'}}...))
+	trips := Evaluate(MaskOf([]Card{{2, 'H'}, {2, 'D'}, {2, 'C'}, {3, 'S'}, {4, 'H'}}...))
+	winner = []int{1}
+	best = trips
+	registerPlayerHand(2, twoPairs, &best, &winner)
+	if !EqualIntSlice(winner, []int{1}) || best != trips {
+		t.Error("Player hand registered incorrectly")
+	}
+
+	candidate := Evaluate(MaskOf([]Card{{6, 'H'}, {6, 'D'}, {5, 'C'}, {5, 'S'}, {2, 'C'}}...))
+	existing := Evaluate(MaskOf([]Card{{6, 'C'}, {6, 'S'}, {5, 'H'}, {5, 'D'}, {2, 'S'}}...))
+	winner = []int{1}
+	best = existing
+	registerPlayerHand(2, candidate, &best, &winner)
+	if !EqualIntSlice(winner, []int{1, 2}) {
+		t.Error("Player hand registered incorrectly")
+	}
+}
+
+func TestRegisterGameResult(t *testing.T) {
+	stats := make([]playerStats, 3)
+	registerGameResult(gameResult{Winners: []int{0}}, stats)
+	registerGameResult(gameResult{Winners: []int{0, 2}}, stats)
+	registerGameResult(gameResult{Winners: []int{0, 1, 2}}, stats)
+	registerGameResult(gameResult{Winners: []int{1}, Classes: []string{"", "AKs", ""}}, stats)
+
+	if stats[0].Wins != 1 || stats[0].Ties != 2 || stats[1].Wins != 1 || stats[1].Ties != 1 {
+		t.Error("Game results registered incorrectly")
+	}
+	if stats[0].equity(4) != (1+0.5+1.0/3)/4 {
+		t.Error("Split pots should give each winner an equal share")
+	}
+	total := stats[0].equity(4) + stats[1].equity(4) + stats[2].equity(4)
+	if total < 0.999999 || total > 1.000001 {
+		t.Error("Player equities should add up to the whole pot")
+	}
+	if stats[1].SampledClasses["AKs"] != 1 || len(stats[0].SampledClasses) != 0 {
+		t.Error("Sampled hand classes registered incorrectly")
+	}
+}
+
+func TestHandCategoryDistribution(t *testing.T) {
+	stats := make([]playerStats, 2)
+	registerGameResult(gameResult{Winners: []int{0}, Categories: []int8{categoryFlush, categoryOnePair}}, stats)
+	registerGameResult(gameResult{Winners: []int{1}, Categories: []int8{categoryFlush, categoryFullHouse}}, stats)
+	registerGameResult(gameResult{Winners: []int{0, 1}, Categories: []int8{categoryOnePair, categoryOnePair}}, stats)
+	registerGameResult(gameResult{Winners: []int{0}, Categories: []int8{categoryFlush, categoryHighCard}}, stats)
+
+	flush := stats[0].Categories[categoryFlush]
+	if flush.Games != 3 || flush.Wins != 2 || flush.Ties != 0 || stats[0].Categories[categoryOnePair].Ties != 1 {
+		t.Errorf("Hand categories registered incorrectly: %+v", stats[0].Categories)
+	}
+
+	reports := stats[1].categoryReports(4, StandardRanking)
+	if len(reports) != 3 || reports[0].Category != "Full House" || reports[2].Category != "High Card" {
+		t.Fatalf("Categories should be listed strongest first: %+v", reports)
+	}
+	if reports[0].Win != 1 || reports[1].Frequency != 0.5 || reports[1].Tie != 0.5 || reports[2].Win != 0 {
+		t.Errorf("Category reports incorrect: %+v", reports)
+	}
+}
+
+func TestStreetProgression(t *testing.T) {
+	stats := make([]playerStats, 2)
+	// Ahead on the flop and the turn, but outdrawn at the river
+	registerGameResult(gameResult{Winners: []int{1}, Leaders: [][]int{nil, {0}, {0}}}, stats)
+	// Behind on the flop, caught up on the turn
+	registerGameResult(gameResult{Winners: []int{0}, Leaders: [][]int{nil, {1}, {0, 1}}}, stats)
+	// Only the river was dealt
+	registerGameResult(gameResult{Winners: []int{0}}, stats)
+
+	flop, turn := stats[0].Streets[1], stats[0].Streets[2]
+	if flop.Ahead != 1 || flop.AheadAndWon != 0 || flop.BehindButWon != 1 {
+		t.Errorf("Flop registered incorrectly: %+v", flop)
+	}
+	if turn.Ahead != 2 || turn.AheadAndWon != 1 || stats[1].Streets[2].Ahead != 1 {
+		t.Errorf("Turn registered incorrectly: %+v", turn)
+	}
+
+	reports := stats[0].streetReports(4)
+	if len(reports) != 2 || reports[0].Street != "flop" || reports[1].Leads.AheadButLost != 0.25 || reports[0].Equity != nil {
+		t.Errorf("Street reports incorrect: %+v", reports)
+	}
+
+	// From the flop the simulation only deals the turn
+	game := Game{Deck: createDeck(), Streets: true}
+	AddPlayer(&game, "As Ks")
+	AddPlayer(&game, "Qh Qd")
+	SetBoard(&game, "2c 7h 8s")
+	result := simulateGames(context.Background(), game, Config{Workers: 2, Iterations: 1000, Seed: 1})
+	if _, ok := result.Stats[0].Streets[1]; ok || result.Stats[1].Streets[2].Ahead == 0 {
+		t.Errorf("Expected only the turn to be tracked: %+v", result.Stats[1].Streets)
+	}
+
+	// The streets aren't tracked unless asked for
+	game.Streets = false
+	result = simulateGames(context.Background(), game, Config{Workers: 2, Iterations: 1000, Seed: 1})
+	if result.Stats[1].Streets != nil {
+		t.Errorf("Expected no streets to be tracked: %+v", result.Stats[1].Streets)
+	}
+}
+
+func TestStreetEquity(t *testing.T) {
+	// On the turn AsKs only wins on the 6 aces and kings left in the 44 cards
+	game := NewGame()
+	AddPlayer(&game, "AsKs")
+	AddPlayer(&game, "QhQd")
+	SetBoard(&game, "2c7h8s9d")
+	runouts := playOutRunouts(game, game.Table.Cards, game.Hands, game.Deck)
+	if runouts[0].Runouts != 44 || runouts[0].Wins != 6 || runouts[1].Wins != 38 {
+		t.Errorf("Expected 6 and 38 winning rivers out of 44, got %+v", runouts)
+	}
+	if equityBucket(runouts[0].equity()) != 1 || equityBucket(1) != streetEquityBuckets-1 {
+		t.Errorf("Equity put in the wrong part: %v", runouts[0].equity())
+	}
+
+	// The equity after every street is averaged over the same games as the final equity
+	config := Config{Hands: []string{"AsKs", "QhQd"}, Iterations: 200, Workers: 2, Seed: 1, StreetEquity: true}
+	result, err := Simulate(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for streetIndex, street := range []string{"flop", "turn"} {
+		mean, games := 0.0, 0.0
+		for _, player := range result.Players {
+			report := player.Streets[streetIndex]
+			if report.Street != street || report.Equity == nil || len(report.Equity.Distribution) != streetEquityBuckets {
+				t.Fatalf("Expected the equity on the %v: %+v", street, report)
+			}
+			mean += report.Equity.Mean
+			for _, share := range report.Equity.Distribution {
+				games += share
+			}
+		}
+		if math.Abs(mean-1) > 1e-9 || math.Abs(games-2) > 1e-9 {
+			t.Errorf("Expected the %v equities to add up to 1 and every game to be counted, got %v and %v", street, mean, games)
+		}
+	}
+}
+
+func TestAddingCardToDeck(t *testing.T) {
+	deck := createDeck()
+	addCardToTable(Card{1, 'S'}, &deck)
+	if deck.Contains(Card{1, 'S'}) || !deck.Contains(Card{1, 'H'}) || deck.Count() != 51 {
+		t.Error("Card was not removed from deck")
+	}
+	if err := checkDeckHealth(deck, Card{1, 'S'}.mask(), 0, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddingHandToTable(t *testing.T) {
+	hand := Hand{Cards: MaskOf(
+		Card{10, 'C'},
+		Card{1, 'D'},
+	)}
+	deck := createDeck()
+	var hands []Hand
+	addHandToTable(hand, &deck, &hands)
+	if hands[0] != hand {
+		t.Errorf("Hand hasn't been added to the table")
+	}
+	if deck.Count() != 50 || deck&hand.Cards != 0 {
+		t.Errorf("Hand hasn't been removed from the deck")
+	}
+}
+
+func TestGettingRandomCard(t *testing.T) {
+	deck := createDeck()
+	crds := getRandomCardsFromDeck(&deck, 2, rng)
+	if deck.Count() != 50 || crds.Count() != 2 {
+		t.Errorf("Did not extract random cards")
+	}
+	if deck&crds != 0 {
+		t.Errorf("Did not extract random cards")
+	}
+
+	deck2 := createDeck()
+	crds = getRandomCardsFromDeck(&deck2, 0, rng)
+	if deck2.Count() != 52 || crds != 0 {
+		t.Errorf("Did not extract random cards")
+	}
+	if err := checkDeckHealth(deck2, crds, 0, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCardMask(t *testing.T) {
+	deck := createDeck()
+	cards := deck.Cards()
+	if len(cards) != 52 || MaskOf(cards...) != deck {
+		t.Error("Cards don't survive a round trip through a mask")
+	}
+	for i, single := range deck.singles() {
+		if single != deck.nth(i) || single != cards[i].mask() {
+			t.Errorf("Card %v is not in the expected position", cards[i])
+		}
+	}
+	if (Card{1, 'H'}).mask() == (Card{14, 'H'}).mask()>>13 || !deck.Contains(Card{1, 'S'}) {
+		t.Error("Aces are not stored as the highest card")
+	}
+}
+
+func TestTwoPairCheck(t *testing.T) {
+	cards := []Card{
+		{5, 'H'},
+		{6, 'H'},
+		{1, 'D'},
+		{1, 'H'},
+		{2, 'H'},
+		{7, 'S'},
+		{2, 'S'},
+	}
+	expectedKickers := []Card{
+		{7, 'S'},
+	}
+
+	pairs, kickers := checkTwoPairs(cards)
+	expectedPairs := []int8{1, 2}
+	if !cardSliceContainsSameCards(expectedKickers, kickers) || !EqualInt8Slice(expectedPairs, pairs) {
+		t.Errorf("Two Pairs not found")
+	}
+}
+
+func TestCheckMultipleValues(t *testing.T) {
+	cards := []Card{
+		{5, 'H'},
+		{6, 'H'},
+		{7, 'H'},
+		{2, 'H'},
+		{3, 'H'},
+		{1, 'H'},
+		{1, 'S'},
+	}
+
+	result, kickers := checkMultiples(cards, 2, 3)
+	expectedKickers := []Card{
+		{5, 'H'},
+		{6, 'H'},
+		{7, 'H'},
+	}
+
+	if result != 1 || len(kickers) != 3 || !cardSliceContainsSameCards(expectedKickers, kickers) {
+		t.Errorf("Pair not found")
+	}
+
+	cards = []Card{
+		{5, 'H'},
+		{7, 'H'},
+		{7, 'S'},
+		{1, 'H'},
+		{1, 'S'},
+	}
+	result, kickers = checkMultiples(cards, 2, 3)
+	expectedKickers = []Card{
+		{5, 'H'},
+		{7, 'H'},
+		{7, 'S'},
+	}
+
+	if result != 1 || len(kickers) != 3 || !cardSliceContainsSameCards(expectedKickers, kickers) {
+		t.Errorf("Pair not found")
+	}
+
+	cards = []Card{
+		{1, 'H'},
+		{7, 'H'},
+		{7, 'S'},
+		{7, 'C'},
+		{1, 'S'},
+	}
+	result, kickers = checkMultiples(cards, 3, 2)
+	expectedKickers = []Card{
+		{1, 'H'},
+		{1, 'S'},
+	}
+
+	if result != 7 || len(kickers) != 2 || !cardSliceContainsSameCards(expectedKickers, kickers) {
+		t.Errorf("Pair not found")
+	}
+}
+
+func TestStraight(t *testing.T) {
+	cards := []Card{
+		{1, 'H'},
+		{2, 'H'},
+		{3, 'S'},
+		{4, 'C'},
+		{5, 'S'},
+		{7, 'S'},
+		{8, 'S'},
+	}
+	straight := checkStraight(cards)
+	if straight != 5 {
+		t.Errorf("Straight not found")
+	}
+
+	cards = []Card{
+		{1, 'H'},
+		{2, 'H'},
+		{3, 'S'},
+		{4, 'C'},
+		{5, 'S'},
+		{6, 'S'},
+		{7, 'S'},
+	}
+	straight = checkStraight(cards)
+	if straight != 7 {
+		t.Errorf("Straight not found")
+	}
+
+	cards = []Card{
+		{1, 'H'},
+		{2, 'H'},
+		{9, 'S'},
+		{10, 'C'},
+		{11, 'S'},
+		{12, 'S'},
+		{13, 'S'},
+	}
+	straight = checkStraight(cards)
+	if straight != 14 {
+		t.Errorf("Straight not found")
+	}
+}
+
+func TestStraightFlush(t *testing.T) {
+	cards := []Card{
+		{1, 'H'},
+		{9, 'C'},
+		{10, 'C'},
+		{11, 'C'},
+		{12, 'C'},
+		{13, 'C'},
+		{6, 'S'},
+	}
+	if checkStraightFlush(cards) != 13 {
+		t.Errorf("Straight flush not found")
+	}
+
+	cards[1] = Card{9, 'S'}
+	if checkStraightFlush(cards) != 0 {
+		t.Errorf("Straight and flush are not a straight flush")
+	}
+}
+
+func TestFlush(t *testing.T) {
+	cards := []Card{
+		{1, 'S'},
+		{2, 'H'},
+		{3, 'S'},
+		{4, 'C'},
+		{5, 'S'},
+		{7, 'S'},
+		{8, 'S'},
+	}
+	straight := checkFlush(cards)
+	expected := []int8{1, 3, 5, 7, 8}
+	if !EqualInt8Slice(expected, straight) {
+		t.Errorf("Flush not found")
+	}
+
+	cards = []Card{
+		{1, 'C'},
+		{2, 'H'},
+		{3, 'S'},
+		{4, 'C'},
+		{5, 'S'},
+		{7, 'S'},
+		{8, 'S'},
+	}
+	straight = checkFlush(cards)
+	expected = []int8{}
+	if !EqualInt8Slice(expected, straight) {
+		t.Errorf("Flush not found")
+	}
+}
+
+func TestCountCardCombinations(t *testing.T) {
+	if countCardCombinations(45, 2) != 990 {
+		t.Error("Wrong number of turn and river combinations")
+	}
+	if countCardCombinations(48, 5) != 1712304 {
+		t.Error("Wrong number of preflop board combinations")
+	}
+	if countCardCombinations(44, 0) != 1 || countCardCombinations(2, 3) != 0 {
+		t.Error("Wrong number of edge case combinations")
+	}
+}
+
+func TestForEachCardCombination(t *testing.T) {
+	deck := MaskOf(createDeck().Cards()[:6]...)
+	seen := make(map[CardMask]bool)
+	forEachCardCombination(deck, 2, func(cards CardMask) {
+		if cards.Count() != 2 || cards&deck != cards {
+			t.Error("Combination doesn't hold two cards from the deck")
+		}
+		seen[cards] = true
+	})
+	if len(seen) != countCardCombinations(6, 2) {
+		t.Errorf("Expected %v combinations, got %v", countCardCombinations(6, 2), len(seen))
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	expectations := map[string]int{
+		"AKs":          4,
+		"AKo":          12,
+		"AK":           16,
+		"TT":           6,
+		"TT+":          30,
+		"A2s-A5s":      16,
+		"KTo+":         36,
+		"22-44":        18,
+		"AhKd":         1,
+		"TT+, AKs, QQ": 34,
+		"AKs:0.5, QQ":  10,
+	}
+	for text, expected := range expectations {
+		r, err := ParseRange(text)
+		if err != nil {
+			t.Errorf("Range %v should be valid: %v", text, err)
+			continue
+		}
+		if len(r.Combos) != expected {
+			t.Errorf("Range %v should have %v combos, got %v", text, expected, len(r.Combos))
+		}
+	}
+
+	for _, text := range []string{"", "AKx", "TTs", "A2s-K5s", "7H 11S", "1H13H", "AhAh", "AKs:2", "AKs:x", "AKs:0"} {
+		if _, err := ParseRange(text); err == nil {
+			t.Errorf("Range %q should be invalid", text)
+		}
+	}
+
+	r, _ := ParseRange("A5s")
+	for _, combo := range r.Combos {
+		cards := combo.Hand.Cards.Cards()
+		if combo.Class != "A5s" || cards[0].Number != 5 || cards[1].Number != 1 || cards[0].Suit != cards[1].Suit {
+			t.Errorf("Unexpected combo %v in A5s", combo)
+		}
+	}
+
+	// A list of specific combos is a range, not one hand
+	r, err := parsePlayerInput("AhKd, AsKs", Holdem)
+	if err != nil || len(r.Combos) != 2 {
+		t.Errorf("Expected a range of 2 combos, got %v %v", r.Combos, err)
+	}
+	if _, err := parsePlayerInput("AhKdQs", Holdem); !errors.Is(err, ErrHandSize) {
+		t.Errorf("Expected %v, got %v", ErrHandSize, err)
+	}
+}
+
+func TestWeightedRange(t *testing.T) {
+	r, err := ParseRange("AKs:0.25, QQ:1, 76s:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, combo := range r.Combos {
+		expected := map[string]float64{"AKs": 0.25, "QQ": 1, "76s": 0}[combo.Class]
+		if combo.Weight != expected {
+			t.Errorf("Combo %v should have weight %v", combo.Class, expected)
+		}
+	}
+
+	counts := make(map[string]int)
+	deck := createDeck()
+	for i := 0; i < 10000; i++ {
+		combo, _ := r.dealHand(deck, rng)
+		counts[combo.Class]++
+	}
+	// AKs has 4 combos at 0.25 against 6 QQ combos at full weight
+	if counts["76s"] != 0 || counts["AKs"] < 1000 || counts["AKs"] > 2000 {
+		t.Errorf("Weights are not respected: %v", counts)
+	}
+}
+
+func TestDealRangeHands(t *testing.T) {
+	deck := createDeck()
+	addCardToTable(Card{1, 'H'}, &deck)
+	addCardToTable(Card{1, 'S'}, &deck)
+	aces, _ := ParseRange("AA")
+	kings, _ := ParseRange("KK")
+	dealt := make([]RangeCombo, 3)
+	ranges := []*Range{&aces, nil, &kings}
+
+	for i := 0; i < 100; i++ {
+		gameDeck := deck
+		if !dealRangeHands(ranges, dealt, &gameDeck, rng) {
+			t.Fatal("Ranges should be dealt")
+		}
+		if dealt[0].Hand.Cards != MaskOf(Card{1, 'D'}, Card{1, 'C'}) {
+			t.Errorf("Only AdAc is left from the aces range, got %v", dealt[0].Hand.Cards.Cards())
+		}
+		if dealt[2].Hand.Cards.Cards()[0].Number != 13 || dealt[2].Class != "KK" || gameDeck.Count() != 46 {
+			t.Error("Kings range dealt incorrectly")
+		}
+	}
+
+	onlyAces, _ := ParseRange("AhAs")
+	if dealRangeHands([]*Range{&onlyAces}, dealt, &deck, rng) {
+		t.Error("Blocked range should not be dealt")
+	}
+}
+
+func TestEvaluateHandCategories(t *testing.T) {
+	hands := map[int8][]Card{
+		categoryStraightFlush: {{1, 'H'}, {2, 'H'}, {3, 'H'}, {4, 'H'}, {5, 'H'}, {13, 'S'}, {13, 'D'}},
+		categoryPoker:         {{9, 'H'}, {9, 'D'}, {9, 'C'}, {9, 'S'}, {5, 'H'}, {13, 'S'}, {13, 'D'}},
+		categoryFullHouse:     {{9, 'H'}, {9, 'D'}, {9, 'C'}, {13, 'H'}, {13, 'S'}, {13, 'D'}, {2, 'D'}},
+		categoryFlush:         {{1, 'S'}, {2, 'H'}, {3, 'S'}, {4, 'C'}, {5, 'S'}, {7, 'S'}, {8, 'S'}},
+		categoryStraight:      {{10, 'S'}, {11, 'H'}, {12, 'S'}, {13, 'C'}, {1, 'D'}, {7, 'S'}, {8, 'S'}},
+		categoryTrips:         {{10, 'S'}, {10, 'H'}, {10, 'D'}, {13, 'C'}, {1, 'D'}, {7, 'S'}, {8, 'S'}},
+		categoryTwoPairs:      {{10, 'S'}, {10, 'H'}, {7, 'D'}, {13, 'C'}, {1, 'D'}, {7, 'S'}, {8, 'S'}},
+		categoryOnePair:       {{10, 'S'}, {10, 'H'}, {2, 'D'}, {13, 'C'}, {1, 'D'}, {7, 'S'}, {8, 'S'}},
+		categoryHighCard:      {{10, 'S'}, {4, 'H'}, {2, 'D'}, {13, 'C'}, {1, 'D'}, {7, 'S'}, {8, 'S'}},
+	}
+	for expected, cards := range hands {
+		if category := RankCategory(Evaluate(MaskOf(cards...))); category != expected {
+			t.Errorf("Expected %v, got %v", CombinationName(expected), CombinationName(category))
+		}
+	}
+}
+
+func TestOmahaUsesTwoHoleCards(t *testing.T) {
+	hands := []struct {
+		hand, board   string
+		holdem, omaha int8
+	}{
+		{"AhKh2c3d", "QhJhTh4s5s", categoryStraightFlush, categoryStraightFlush},
+		{"Ah2c3d4s", "KhQhJhTh9c", categoryStraightFlush, categoryHighCard},
+		{"2c2d4d5d", "9h9d9c9sKh", categoryPoker, categoryFullHouse},
+	}
+	for _, test := range hands {
+		hand, board := mustCards(t, test.hand), mustCards(t, test.board)
+		if category := RankCategory(NewGame().evaluate(board, hand)); category != test.holdem {
+			t.Errorf("%v on %v: expected %v in hold'em, got %v", test.hand, test.board,
+				CombinationName(test.holdem), CombinationName(category))
+		}
+		for _, variant := range []Variant{Omaha, Omaha5} {
+			if category := RankCategory(NewVariantGame(variant).evaluate(board, hand)); category != test.omaha {
+				t.Errorf("%v on %v: expected %v in %v, got %v", test.hand, test.board,
+					CombinationName(test.omaha), variant, CombinationName(category))
+			}
+		}
+	}
+}
+
+func TestShortDeck(t *testing.T) {
+	if deck := createShortDeck(); deck.Count() != 36 || deck.Contains(Card{5, 'S'}) || !deck.Contains(Card{6, 'S'}) {
+		t.Errorf("Expected 36 cards from 6 up, got %v", deck)
+	}
+
+	// The ace plays below the 6, which only makes a straight without the 2 to 5
+	wheel := mustCards(t, "Ah6c7d8s9hKcKd")
+	straight := categoryStraight
+	if RankCategory(EvaluateShortDeck(wheel)) != straight || RankCategory(Evaluate(wheel)) == straight {
+		t.Errorf("Expected A-6-7-8-9 to be a straight in the short deck only")
+	}
+	if EvaluateShortDeck(wheel) >= EvaluateShortDeck(mustCards(t, "6c7d8s9hThKcKd")) {
+		t.Errorf("Expected A-6-7-8-9 to be the lowest straight")
+	}
+
+	// The flush beats the full house
+	config := Config{Hands: []string{"9h8h", "AsAd"}, Board: "AhKhQh6s6d"}
+	for variant, winner := range map[Variant]int{Holdem: 1, ShortDeck: 0} {
+		config.Variant = variant
+		result, err := Simulate(context.Background(), config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Players[winner].Win != 1 {
+			t.Errorf("Expected player %v to win in %v: %+v", winner, variant, result.Players)
+		}
+	}
+
+	// Any order can be set, here trips beat a straight
+	ranking, err := ParseHandRanking("straight flush, poker, flush, full house, trips, straight, two pairs, one pair, high card")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config = Config{Variant: ShortDeck, Ranking: ranking, Hands: []string{"TcJc", "6s6d"}, Board: "6h7d8s9hKc"}
+	result, err := Simulate(context.Background(), config)
+	if err != nil || result.Players[1].Win != 1 || len(result.Ranking) != 9 || result.Ranking[4] != "Trips" {
+		t.Errorf("Expected the trips to win: %+v %v", result, err)
+	}
+
+	// A hand making two categories plays the one ranked higher, even when it's the lower one in the usual order
+	straightOverFlush, err := ParseHandRanking("straight flush, poker, full house, straight, flush, trips, two pairs, one pair, high card")
+	if err != nil {
+		t.Fatal(err)
+	}
+	both := map[string]Config{
+		"trips over straight": {Ranking: ranking, Hands: []string{"8c9h", "8dKs"}, Board: "5c6d7h9s9d"},
+		"straight over flush": {Ranking: straightOverFlush, Hands: []string{"Th2h", "5s4c"}, Board: "6h7h8h9dKh"},
+	}
+	for name, config := range both {
+		result, err := Simulate(context.Background(), config)
+		if err != nil || result.Players[0].Win != 1 {
+			t.Errorf("%v: expected the hand making both categories to win: %+v %v", name, result, err)
+		}
+	}
+
+	invalid := map[string]struct {
+		config Config
+		err    error
+	}{
+		"low card":      {Config{Variant: ShortDeck, Hands: []string{"Ah2h"}}, ErrCardNotInDeck},
+		"low board":     {Config{Variant: ShortDeck, Hands: []string{"AhKh"}, Board: "5c6c7c"}, ErrCardNotInDeck},
+		"short ranking": {Config{Hands: []string{"AhKh"}, Ranking: HandRanking{1, 2, 3}}, ErrRanking},
+		"twice":         {Config{Hands: []string{"AhKh"}, Ranking: HandRanking{1, 2, 3, 4, 5, 6, 7, 8, 8}}, ErrRanking},
+	}
+	for name, test := range invalid {
+		if _, err := test.config.Game(); !errors.Is(err, test.err) {
+			t.Errorf("%v: expected %v, got %v", name, test.err, err)
+		}
+	}
+	if _, err := ParseHandRanking("poker, flush, quads"); err == nil {
+		t.Errorf("Expected an unknown category")
+	}
+}
+
+func TestStud(t *testing.T) {
+	hand, err := parseStudHand("AhAd/Ks")
+	if err != nil || hand.Cards != mustCards(t, "AhAdKs") || hand.Up != mustCards(t, "Ks") || hand.String() != "AhAd/Ks" {
+		t.Errorf("Expected two downcards and an upcard, got %+v %v", hand, err)
+	}
+
+	// On seventh street every card is known, so the trips always win
+	config := Config{Variant: Stud, Hands: []string{"AhAd2c/KsKd7h8h", "9c9d9h/QsJs3d4c"}, Iterations: 100}
+	result, err := Simulate(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Exact || result.Players[1].Win != 1 || result.Players[0].Hand != "AhAd2c/KdKs8h7h" {
+		t.Errorf("Expected the trips to win every game: %+v", result.Players)
+	}
+
+	// Folded upcards are out of the deck, the rest of the cards are dealt to every player
+	config = Config{Variant: Stud, Hands: []string{"AhAd/Ks", "/9c", "/Qs"}, Dead: "KdKcKh", Iterations: 2000, Seed: 9,
+		HiLo: true}
+	game := mustGame(t, config)
+	if needed, _ := game.cardsNeeded(); needed != 4+6+6 || game.Deck.Count() != 52-5-3 {
+		t.Errorf("Expected 16 cards to be dealt from 44, got %v from %v", needed, game.Deck.Count())
+	}
+	result, err = Simulate(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0.0
+	for _, player := range result.Players {
+		total += player.Equity
+	}
+	if result.Iterations != 2000 || result.HiLo == nil || math.Abs(total-1) > 1e-9 {
+		t.Errorf("Expected a sampled stud eight or better run: %+v", result)
+	}
+
+	invalid := map[string]struct {
+		config Config
+		err    error
+	}{
+		"board":         {Config{Variant: Stud, Hands: []string{"AhAd/Ks"}, Board: "2c3c4c"}, ErrBoardSize},
+		"street":        {Config{Variant: Stud, Hands: []string{"AhAd/Ks", "/9c9d"}}, ErrStreet},
+		"downcards":     {Config{Variant: Stud, Hands: []string{"AhAd2c/Ks"}}, ErrHandSize},
+		"no upcards":    {Config{Variant: Stud, Hands: []string{"AhAd/"}}, ErrHandSize},
+		"down and up":   {Config{Variant: Stud, Hands: []string{"AhAd/Ah"}}, ErrDuplicateCard},
+		"range":         {Config{Variant: Stud, Hands: []string{"AhAd/Ks", "QQ+"}}, nil},
+		"missing slash": {Config{Variant: Stud, Hands: []string{"AhAdKs"}}, nil},
+	}
+	for name, test := range invalid {
+		_, err := test.config.Game()
+		if err == nil || (test.err != nil && !errors.Is(err, test.err)) {
+			t.Errorf("%v: expected %v, got %v", name, test.err, err)
+		}
+	}
+}
+
+func TestEvaluateLow(t *testing.T) {
+	// From the best low down, straights and flushes don't count
+	lows := []string{"Ah2h3h4h5h", "Ad2c3s4h6d", "2c3d4h5s7c", "Ac2d3h5s8c", "4c5d6h7s8c"}
+	var previous uint32
+	for i, low := range lows {
+		rank := EvaluateLow(mustCards(t, low))
+		if rank == 0 || (i > 0 && rank >= previous) {
+			t.Errorf("Expected %v to be a worse low than %v", low, lows[i-1])
+		}
+		previous = rank
+	}
+	// The best five face values are picked, pairs and cards above 8 are left out
+	if EvaluateLow(mustCards(t, "AhAd2c3d4s5h9c")) != EvaluateLow(mustCards(t, "Ah2c3d4s5h")) {
+		t.Errorf("Expected the wheel out of seven cards")
+	}
+	for _, noLow := range []string{"Ah2c3d4s9h", "AhAd2c3d4s", "KhQdJc9s8h7c6d"} {
+		if rank := EvaluateLow(mustCards(t, noLow)); rank != 0 {
+			t.Errorf("Expected %v not to qualify, got %v", noLow, rank)
+		}
+	}
+	// In Omaha two hole cards have to play for the low too
+	if NewVariantGame(Omaha).evaluateLow(mustCards(t, "2c3d4h5sQc"), mustCards(t, "AhKdKsQh")) != 0 {
+		t.Errorf("Expected no Omaha low with a single low hole card")
+	}
+	if NewGame().evaluateLow(mustCards(t, "2c3d4h5sQc"), mustCards(t, "AhKd")) == 0 {
+		t.Errorf("Expected a hold'em low with the ace")
+	}
+}
+
+func TestEvaluateLowball(t *testing.T) {
+	evaluators := map[string]struct {
+		evaluate func(CardMask) uint32
+		// From the best low down
+		lows []string
+	}{
+		"deuce to seven": {EvaluateDeuceToSeven, []string{"7h5d4c3s2h", "7h6d4c3s2h", "8h6d4c3s2h", "Kh8d5c4s3h",
+			"Ah5d4c3s2h", "2h2d5c4s3h", "AhAd5c4s3h", "6h5d4c3s2h", "7h5h4h3h2h"}},
+		"ace to five": {EvaluateAceToFive, []string{"Ah2h3h4h5h", "6h4d3c2sAh", "6h5d4c3s2h", "Kh8d5c4s3h",
+			"AhAd4c3s2h", "2h2d5c4s3h", "2h2d3c3s4h"}},
+	}
+	for name, test := range evaluators {
+		var previous uint32
+		for i, low := range test.lows {
+			rank := test.evaluate(mustCards(t, low))
+			if i > 0 && rank >= previous {
+				t.Errorf("%v: expected %v to be a worse low than %v", name, low, test.lows[i-1])
+			}
+			previous = rank
+		}
+	}
+
+	// The best five cards play, the category is the high hand they make
+	seven := mustCards(t, "AhAd2c3s4h5d5c")
+	if EvaluateAceToFive(seven) != EvaluateAceToFive(mustCards(t, "Ad2c3s4h5d")) {
+		t.Errorf("Expected the wheel out of seven cards")
+	}
+	if category := RankCategory(EvaluateAceToFive(mustCards(t, "Ah2h3h4h5h"))); category != categoryHighCard {
+		t.Errorf("Expected straights and flushes not to count in ace to five, got %v", CombinationName(category))
+	}
+	if category := RankCategory(EvaluateDeuceToSeven(mustCards(t, "6h5d4c3s2h"))); category != categoryStraight {
+		t.Errorf("Expected a straight in deuce to seven, got %v", CombinationName(category))
+	}
+}
+
+func TestLowballGames(t *testing.T) {
+	games := map[string]Config{
+		// On seventh street every card is known
+		"razz": {Variant: Razz, Hands: []string{"Ah2c3d/4s5h9cKd", "6h6d7c/8s9hTcJd"}, Iterations: 100},
+		// The seven low is the best hand there is, whatever the draw brings
+		"deuce to seven": {Variant: DeuceToSeven, Hands: []string{"7h5d4c3s2h", "8h6d"}, Iterations: 1000, Seed: 3},
+	}
+	for name, config := range games {
+		result, err := Simulate(context.Background(), config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Exact || result.Players[0].Win != 1 {
+			t.Errorf("%v: expected the first player to win every game: %+v", name, result.Players)
+		}
+	}
+
+	invalid := map[string]struct {
+		config Config
+		err    error
+	}{
+		"too many kept": {Config{Variant: DeuceToSeven, Hands: []string{"7h5d4c3s2hKh"}}, ErrHandSize},
+		"board":         {Config{Variant: DeuceToSeven, Hands: []string{"7h5d"}, Board: "2c3c4c"}, ErrBoardSize},
+		"street":        {Config{Variant: Razz, Hands: []string{"Ah2c/3d", "/4s5s"}}, ErrStreet},
+		"hi/lo":         {Config{Variant: Razz, Hands: []string{"Ah2c/3d"}, HiLo: true}, nil},
+		"ranking":       {Config{Variant: DeuceToSeven, Hands: []string{"7h5d"}, Ranking: StandardRanking}, nil},
+	}
+	for name, test := range invalid {
+		_, err := test.config.Game()
+		if err == nil || (test.err != nil && !errors.Is(err, test.err)) {
+			t.Errorf("%v: expected %v, got %v", name, test.err, err)
+		}
+	}
+}
+
+func TestKeepForDraw(t *testing.T) {
+	hands := map[string]string{
+		// A nine low or better stands pat
+		"9h7d5c3s2h": "9h7d5c3s2h",
+		// Only one card of every value up to the seven is kept
+		"Kh7d7c4s2h": "7d4s2h",
+		"AhKdQcJsTh": "",
+		// The highest card of a straight or a flush goes
+		"6h5d4c3s2h": "5d4c3s2h",
+		"7h5h4h3h2h": "5h4h3h2h",
+	}
+	for hand, expected := range hands {
+		if kept := keepForDraw(mustCards(t, hand)); kept != mustCards(t, expected) {
+			t.Errorf("%v: expected to keep %v, got %v", hand, expected, kept)
+		}
+	}
+
+	// The seven low stands pat over all three draws, another seven low can only tie it
+	config := Config{Variant: TripleDraw, Hands: []string{"7h5d4c3s2h", "8h6d"}, Iterations: 1000, Seed: 3}
+	result, err := Simulate(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if player := result.Players[0]; player.Win+player.Tie != 1 || player.Win < 0.9 {
+		t.Errorf("Expected the seven low to never lose: %+v", player)
+	}
+
+	// Two more draws leave the drawing hand more chances to get there
+	equities := map[Variant]float64{}
+	for _, variant := range []Variant{DeuceToSeven, TripleDraw} {
+		config := Config{Variant: variant, Hands: []string{"7h5d3c2s", "9h8d6c4s3h"}, Iterations: 5000, Seed: 5}
+		result, err := Simulate(context.Background(), config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		equities[variant] = result.Players[0].Equity
+	}
+	if equities[TripleDraw] < equities[DeuceToSeven]+0.1 {
+		t.Errorf("Expected the draw to do better in triple draw: %v", equities)
+	}
+}
+
+func TestHiLoSplitPot(t *testing.T) {
+	// The aces take the high half, the two low hands quarter the low half
+	config := Config{Hands: []string{"AhKh", "2c3c", "2d3d"}, Board: "4d5d8sKsQc", HiLo: true}
+	result, err := Simulate(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []PlayerHiLoReport{{1, 0, 0}, {0, 0.5, 0}, {0, 0.5, 0}}
+	equities := []float64{0.5, 0.25, 0.25}
+	for i, player := range result.Players {
+		if player.HiLo == nil || *player.HiLo != expected[i] || player.Equity != equities[i] {
+			t.Errorf("Player %v: expected %+v and equity %v, got %+v", i, expected[i], equities[i], player)
+		}
+	}
+	if result.HiLo == nil || result.HiLo.NoLow != 0 || result.Players[0].Outs != nil {
+		t.Errorf("Expected a low in every game: %+v", result)
+	}
+
+	// Without a low the high hand scoops
+	config.Board = "KdQs9s8c7c"
+	result, err = Simulate(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.HiLo.NoLow != 1 || result.Players[0].Equity != 1 || result.Players[0].HiLo.Scoop != 1 {
+		t.Errorf("Expected the high hand to scoop: %+v", result.Players[0])
+	}
+
+	config = Config{Variant: Omaha, Hands: []string{"AhAd2c3d", "KsKdQsJs"}, HiLo: true, Iterations: 2000, Seed: 7}
+	result, err = Simulate(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := result.Players[0].Equity + result.Players[1].Equity
+	if math.Abs(total-1) > 1e-9 || result.Players[1].HiLo.LowEquity != 0 || result.Players[0].HiLo.Scoop == 0 {
+		t.Errorf("Expected the pots to be shared out: %+v", result.Players)
+	}
+}
+
+func TestOmahaGame(t *testing.T) {
+	// Only the 9 spades left give the second player a flush with both hole cards
+	config := Config{Variant: Omaha, Hands: []string{"AhAdKhKd", "7s8s9cTc"}, Board: "Qs2s3h4d"}
+	result, err := Simulate(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Game != "omaha" || !result.Exact || result.Iterations != 40 {
+		t.Fatalf("Expected every river to be played: %+v", result)
+	}
+	if result.Players[1].Win != 9.0/40 || result.Players[0].Win != 31.0/40 {
+		t.Errorf("Expected 31 and 9 wins out of 40, got %+v", result.Players)
+	}
+	if outs := result.Players[1].Outs; outs == nil || outs.Count != 9 {
+		t.Errorf("Expected 9 outs, got %+v", outs)
+	}
+
+	result, err = Simulate(context.Background(), Config{Variant: Omaha5, Hands: []string{"AhAdKhKd2c", "7s8s9cTcJc"},
+		Iterations: 2000, Seed: 3})
+	if err != nil || result.Game != "omaha5" || result.Iterations != 2000 {
+		t.Errorf("Expected a sampled PLO5 run: %+v %v", result, err)
+	}
+
+	invalid := map[string]struct {
+		config Config
+		err    error
+	}{
+		"hold'em hand":  {Config{Variant: Omaha, Hands: []string{"AhKh"}}, ErrHandSize},
+		"omaha hand":    {Config{Variant: Omaha5, Hands: []string{"AhAdKhKd"}}, ErrHandSize},
+		"range":         {Config{Variant: Omaha, Hands: []string{"AhAdKhKd", "QQ+"}}, ErrRangeVariant},
+		"too many hole": {Config{Hands: []string{"AhAdKhKd"}}, ErrHandSize},
+	}
+	for name, test := range invalid {
+		if _, err := test.config.Game(); !errors.Is(err, test.err) {
+			t.Errorf("%v: expected %v, got %v", name, test.err, err)
+		}
+	}
+	if variant, err := ParseVariant("PLO5"); err != nil || variant != Omaha5 {
+		t.Errorf("Expected PLO5 to be Omaha5, got %v %v", variant, err)
+	}
+	if _, err := ParseVariant("pineapple"); err == nil {
+		t.Errorf("Expected an unknown game")
+	}
+}
+
+func TestEvaluateHandOrdering(t *testing.T) {
+	// Every hand should beat the one following it
+	ordered := [][]Card{
+		{{10, 'H'}, {11, 'H'}, {12, 'H'}, {13, 'H'}, {1, 'H'}},
+		{{1, 'H'}, {2, 'H'}, {3, 'H'}, {4, 'H'}, {5, 'H'}, {6, 'D'}},
+		{{2, 'H'}, {2, 'D'}, {2, 'C'}, {2, 'S'}, {3, 'H'}},
+		{{1, 'H'}, {1, 'D'}, {1, 'C'}, {3, 'S'}, {3, 'H'}, {2, 'S'}, {2, 'H'}},
+		{{1, 'H'}, {1, 'D'}, {1, 'C'}, {2, 'S'}, {2, 'H'}, {13, 'S'}, {12, 'H'}},
+		{{1, 'H'}, {13, 'H'}, {4, 'H'}, {3, 'H'}, {2, 'H'}},
+		{{1, 'H'}, {12, 'H'}, {11, 'H'}, {10, 'H'}, {9, 'H'}},
+		{{10, 'H'}, {11, 'D'}, {12, 'H'}, {13, 'H'}, {1, 'H'}},
+		{{6, 'H'}, {2, 'D'}, {3, 'H'}, {4, 'H'}, {5, 'H'}, {1, 'S'}, {1, 'D'}},
+		{{1, 'H'}, {2, 'D'}, {3, 'H'}, {4, 'H'}, {5, 'H'}},
+		{{1, 'H'}, {1, 'D'}, {1, 'C'}, {13, 'S'}, {11, 'H'}},
+		{{1, 'H'}, {1, 'D'}, {1, 'C'}, {13, 'S'}, {10, 'H'}},
+		{{9, 'H'}, {9, 'D'}, {8, 'C'}, {8, 'S'}, {7, 'H'}, {7, 'D'}, {6, 'D'}},
+		{{9, 'H'}, {9, 'D'}, {8, 'C'}, {8, 'S'}, {6, 'H'}, {3, 'D'}},
+		{{9, 'H'}, {9, 'D'}, {1, 'C'}, {12, 'S'}, {11, 'H'}},
+		{{9, 'H'}, {9, 'D'}, {1, 'C'}, {12, 'S'}, {10, 'H'}},
+		{{1, 'H'}, {12, 'D'}, {11, 'C'}, {10, 'S'}, {8, 'H'}, {2, 'D'}, {3, 'C'}},
+		{{1, 'H'}, {12, 'D'}, {11, 'C'}, {10, 'S'}, {7, 'H'}, {6, 'D'}, {3, 'C'}},
+	}
+	for i := 1; i < len(ordered); i++ {
+		if Evaluate(MaskOf(ordered[i-1]...)) <= Evaluate(MaskOf(ordered[i]...)) {
+			t.Errorf("Hand %v should beat %v", ordered[i-1], ordered[i])
+		}
+	}
+
+	// Same values in different suits are equal, and unused cards don't matter
+	a := Evaluate(MaskOf([]Card{{1, 'H'}, {1, 'D'}, {9, 'C'}, {8, 'S'}, {7, 'H'}, {3, 'D'}, {2, 'C'}}...))
+	b := Evaluate(MaskOf([]Card{{1, 'C'}, {1, 'S'}, {9, 'H'}, {8, 'D'}, {7, 'C'}, {4, 'S'}, {2, 'D'}}...))
+	if a != b {
+		t.Error("Equal hands should have equal ranks")
+	}
+}
+
+func TestEvaluateHandMatchesCombinationCheckers(t *testing.T) {
+	for i := 0; i < 2000; i++ {
+		deck := createDeck()
+		cards := getRandomCardsFromDeck(&deck, 7, rng)
+		expected := getPlayerCombination(cards.Cards()).CombinationID
+		if category := RankCategory(Evaluate(cards)); category != expected {
+			t.Errorf("Cards %v: evaluator found %v, checkers found %v", cards.Cards(),
+				CombinationName(category), CombinationName(expected))
+		}
+	}
+}
+
+func BenchmarkEvaluateHand(b *testing.B) {
+	deck := createDeck()
+	cards := getRandomCardsFromDeck(&deck, 7, rng)
+	for i := 0; i < b.N; i++ {
+		Evaluate(cards)
+	}
+}
+
+func BenchmarkPlayGame(b *testing.B) {
+	configs := map[string]Config{
+		"hands":  {Hands: []string{"AhKh", "QsQd", "7c6c"}},
+		"ranges": {Hands: []string{"AhKh", "QQ+, AKs", "76s"}, Streets: true},
+		"stud":   {Variant: Stud, Hands: []string{"AhKh/Qd", "QsQc/2c"}, HiLo: true},
+	}
+	for name, config := range configs {
+		game, err := config.Game()
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(name, func(b *testing.B) {
+			stats := newSimulationStats(len(game.Hands))
+			buffers := newGameBuffers(len(game.Hands))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				stats.register(playGame(game, rng, buffers))
+			}
+		})
+	}
+}
+
+func BenchmarkGetPlayerCombination(b *testing.B) {
+	deck := createDeck()
+	cards := getRandomCardsFromDeck(&deck, 7, rng).Cards()
+	for i := 0; i < b.N; i++ {
+		getPlayerCombination(cards)
+	}
+}
+
+func TestGameFromConfig(t *testing.T) {
+	config := Config{Hands: []string{"AhKh", "QQ+"}, Board: "7s 8s 2h"}
+	game, err := config.Game()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := game.Table.status(); status != 1 || game.Deck.Count() != 47 || len(game.Hands) != 2 {
+		t.Error("Game built incorrectly")
+	}
+	if game.Ranges[0] != nil || game.Ranges[1] == nil || game.Hands[0].Cards != MaskOf(Card{1, 'H'}, Card{13, 'H'}) {
+		t.Error("Known hands and ranges mixed up")
+	}
+
+	invalid := []Config{
+		{Hands: []string{"AhKh", "AhQd"}},
+		{Hands: []string{"AhKh"}, Board: "7s8s"},
+		{Hands: []string{"AhKh"}, Board: "7s8sKh"},
+		{Hands: []string{"AhKh"}, Board: "7x8s2h"},
+		{Hands: []string{"AKx"}},
+	}
+	for _, config := range invalid {
+		if _, err := config.Game(); err == nil {
+			t.Errorf("Config %+v should be rejected", config)
+		}
+	}
+}
+
+func TestParseCard(t *testing.T) {
+	valid := map[string]Card{
+		"Ah":  {1, 'H'},
+		"1H":  {1, 'H'},
+		"td":  {10, 'D'},
+		"10h": {10, 'H'},
+		"9c":  {9, 'C'},
+		"13S": {13, 'S'},
+		"Qs":  {12, 'S'},
+		"11d": {11, 'D'},
+	}
+	for text, expected := range valid {
+		card, err := ParseCard(text)
+		if err != nil || card != expected {
+			t.Errorf("Card %v should be %v, got %v (%v)", text, expected, card, err)
+		}
+	}
+
+	for _, text := range []string{"", "A", "Ax", "1x", "0h", "14h", "Xh", "100h", "AKh"} {
+		if _, err := ParseCard(text); err == nil {
+			t.Errorf("Card %q should be invalid", text)
+		}
+	}
+
+	if (Card{1, 'H'}).String() != "Ah" || (Card{10, 'C'}).String() != "Tc" {
+		t.Error("Cards are not formatted in standard notation")
+	}
+}
+
+func TestParseCardList(t *testing.T) {
+	expectations := map[string]int{
+		"":            0,
+		"7s8s2h":      3,
+		"Ah Td 9c":    3,
+		"13S 7S 1H":   3,
+		"10h9c":       2,
+		"AhKd, 10c":   3,
+		"7H 11S":      2,
+		"ah  kd qc 2": -1,
+		"AhAh":        -1,
+		"1H 1h":       -1,
+		"7s8s2x":      -1,
+	}
+	for text, expected := range expectations {
+		cards, err := ParseCards(text)
+		if expected < 0 {
+			if err == nil {
+				t.Errorf("Cards %q should be invalid", text)
+			}
+			continue
+		}
+		if err != nil || len(cards) != expected {
+			t.Errorf("Cards %q should give %v cards, got %v (%v)", text, expected, cards, err)
+		}
+	}
+}
+
+func TestAddPlayerAndBoard(t *testing.T) {
+	game := Game{Deck: createDeck()}
+	for _, text := range []string{"Ah Kh", "12S 12D", "JJ+"} {
+		if err := AddPlayer(&game, text); err != nil {
+			t.Errorf("Player %q should be valid: %v", text, err)
+		}
+	}
+	for _, text := range []string{"1H 2C", "Ah Kh Qh", "7H", "xyz"} {
+		if err := AddPlayer(&game, text); err == nil {
+			t.Errorf("Player %q should be invalid", text)
+		}
+	}
+	if len(game.Hands) != 3 || game.Deck.Count() != 48 {
+		t.Error("Invalid players should not change the game")
+	}
+
+	for _, text := range []string{"Qs 7s 2h", "7s 2h", "7s 2h 2h"} {
+		if err := SetBoard(&game, text); err == nil {
+			t.Errorf("Board %q should be invalid", text)
+		}
+	}
+	if err := SetBoard(&game, "7s 8s 2h"); err != nil || game.Deck.Count() != 45 || game.Table.Cards.Count() != 3 {
+		t.Errorf("Board should be on the table: %v", err)
+	}
+}
+
+func TestSetDeadCards(t *testing.T) {
+	game := Game{Deck: createDeck()}
+	AddPlayer(&game, "Ah Kh")
+	AddPlayer(&game, "QQ")
+	SetBoard(&game, "7s 8s 2h")
+
+	if err := SetDeadCards(&game, "Qs 9d"); err != nil {
+		t.Fatal(err)
+	}
+	if game.Dead != MaskOf(Card{12, 'S'}, Card{9, 'D'}) || game.Deck.Count() != 45 || game.Deck.Contains(Card{12, 'S'}) {
+		t.Error("Dead cards should be out of the deck")
+	}
+	if game.Ranges[1].availableWeight(game.Deck) != 3 {
+		t.Error("The range should lose the combos holding a dead card")
+	}
+
+	for _, text := range []string{"Ah", "8s", "Qs", "Xx"} {
+		if err := SetDeadCards(&game, text); err == nil {
+			t.Errorf("Dead cards %q should be invalid", text)
+		}
+	}
+	if err := SetDeadCards(&game, ""); err != nil || game.Deck.Count() != 45 {
+		t.Error("No dead cards should leave the deck alone")
+	}
+
+	// The river and the range hand still have to come out of the deck
+	var text []string
+	for _, card := range game.Deck.Cards()[3:] {
+		text = append(text, card.String())
+	}
+	if err := SetDeadCards(&game, strings.Join(text, " ")); err == nil {
+		t.Error("Dead cards should leave enough cards in the deck")
+	}
+}
+
+func TestFindOuts(t *testing.T) {
+	game := Game{Deck: createDeck()}
+	AddPlayer(&game, "Ah Kh")
+	AddPlayer(&game, "Qs Qd")
+	SetBoard(&game, "7h 8s 2h 9d")
+	if !hasOuts(game) {
+		t.Fatal("Outs should be found on the turn")
+	}
+
+	outs := findOuts(game)
+	if outs[0].Groups[categoryFlush].Count() != 9 || outs[0].Groups[categoryOnePair].Count() != 6 || outs[0].Count() != 15 {
+		t.Errorf("Expected 9 flush and 6 pair outs, got %+v", outs[0].Groups)
+	}
+	if !outs[1].Groups[categoryTrips].Contains(Card{12, 'C'}) || outs[1].Groups[categoryTrips].Contains(Card{12, 'H'}) {
+		t.Error("The queen of hearts makes a set but also the flush")
+	}
+	if outs[0].Count()+outs[1].Count() != game.Deck.Count() || outs[0].Splits != 0 {
+		t.Error("Every card should win the river for somebody")
+	}
+
+	// Both players hold the broadway straight, so every river splits the pot
+	split := Game{Deck: createDeck()}
+	AddPlayer(&split, "Ah 2c")
+	AddPlayer(&split, "As 3c")
+	SetBoard(&split, "Kd Qd Jd Td")
+	splits := findOuts(split)
+	if splits[0].Splits.Count() != 44 || splits[0].Count() != 0 || splits[1].Splits != splits[0].Splits {
+		t.Errorf("Expected every card to split, got %v", splits[0].Splits)
+	}
+
+	for _, board := range []string{"", "7h 8s 2h 9d 3c"} {
+		riverGame := Game{Deck: createDeck()}
+		AddPlayer(&riverGame, "Ah Kh")
+		SetBoard(&riverGame, board)
+		if hasOuts(riverGame) {
+			t.Errorf("No outs expected on board %q", board)
+		}
+	}
+}
+
+func TestSimulationReport(t *testing.T) {
+	game := Game{Deck: createDeck()}
+	AddPlayer(&game, "Kh Ah")
+	AddPlayer(&game, "QQ+")
+	SetBoard(&game, "2h 7s 8s")
+	SetDeadCards(&game, "3c")
+	result := newSimulationStats(2)
+	result.register(gameResult{Winners: []int{0}, Classes: []string{"", "QQ"}})
+	result.register(gameResult{Winners: []int{0, 1}, Classes: []string{"", "AA"}})
+	result.register(gameResult{})
+
+	report := newResult(game, result, false, 0, time.Second)
+	if report.Board != "8s7s2h" || report.Dead != "3c" || report.Players[0].Hand != "AhKh" || report.Players[1].Hand != "QQ+" {
+		t.Errorf("Inputs reported incorrectly: %+v", report)
+	}
+	if report.Players[0].Equity != 0.75 || report.Players[1].Tie != 0.5 || report.Splits.Probability != 0.5 ||
+		report.SkippedGames != 1 {
+		t.Errorf("Results reported incorrectly: %+v", report)
+	}
+
+	output, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(output, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"board", "iterations", "players", "splits", "elapsed_seconds"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("JSON output is missing %v", key)
+		}
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	// The flop leaves 990 boards, few enough to play all of them
+	result, err := Simulate(context.Background(), Config{Hands: []string{"AhKh", "QsQd"}, Board: "7s8s2h", Workers: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Exact || result.Iterations != 990 || len(result.Players) != 2 || result.Players[0].Outs == nil {
+		t.Errorf("Expected every board to be played: %+v", result)
+	}
+
+	config := Config{Hands: []string{"AhKh", "QQ+"}, Iterations: 2000, Seed: 5}
+	result, err = Simulate(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Exact || result.Iterations != 2000 || result.Seed != 5 || result.Players[1].Hand != "QQ+" {
+		t.Errorf("Expected a sampled run: %+v", result)
+	}
+
+	invalid := []Config{
+		{},
+		{Hands: []string{"AhKh", "QQ+"}},
+		{Hands: []string{"AhKh", "AhQd"}, Iterations: 10},
+		{Hands: []string{"AhAd", "AcAs", "AA"}, Iterations: 10},
+	}
+	for _, config := range invalid {
+		if _, err := Simulate(context.Background(), config); err == nil {
+			t.Errorf("Config %+v should be rejected", config)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Simulate(ctx, config); err != context.Canceled {
+		t.Errorf("Expected a canceled run, got %v", err)
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	invalid := map[string]struct {
+		config Config
+		field  string
+		err    error
+	}{
+		"board size":       {Config{Hands: []string{"AhKh"}, Board: "7s8s", Iterations: 10}, "board", ErrBoardSize},
+		"hand size":        {Config{Hands: []string{"AhKhQh"}, Iterations: 10}, "player 0", ErrHandSize},
+		"duplicate card":   {Config{Hands: []string{"AhKh", "QsQd"}, Board: "Qs7s2h", Iterations: 10}, "board", ErrDuplicateCard},
+		"empty range":      {Config{Hands: []string{"AhAd", "AcAs", "AA"}, Iterations: 10}, "player 2", ErrEmptyRange},
+		"no players":       {Config{Iterations: 10}, "players", ErrNoPlayers},
+		"no iterations":    {Config{Hands: []string{"AhKh", "QQ"}}, "iterations", ErrIterations},
+		"too many dead":    {Config{Hands: []string{"AhKh"}, Board: "7s8s2h9d", Dead: deckExcept(t, "AhKh7s8s2h9d")}, "dead cards", ErrDeckSize},
+		"duplicate input":  {Config{Hands: []string{"AhAh"}, Iterations: 10}, "player 0", ErrDuplicateCard},
+		"colliding ranges": {Config{Hands: []string{"AhAd:1,AhAs:1", "AhAd:1,AhAs:1"}, Iterations: 10}, "ranges", ErrEmptyRange},
+	}
+	for name, test := range invalid {
+		_, err := Simulate(context.Background(), test.config)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != test.field || !errors.Is(err, test.err) {
+			t.Errorf("%v: expected %v on %v, got %v", name, test.err, test.field, err)
+		}
+	}
+
+	// A game built by hand is checked before any worker gets it
+	game := NewGame()
+	game.Hands = []Hand{{Cards: MaskOf(Card{1, 'H'})}}
+	game.Ranges = []*Range{nil}
+	if err := game.validate(); !errors.Is(err, ErrHandSize) {
+		t.Errorf("Expected a hand size error, got %v", err)
+	}
+	game.Hands[0] = Hand{Cards: MaskOf(Card{1, 'H'}, Card{13, 'H'})}
+	if err := game.validate(); !errors.Is(err, ErrDuplicateCard) {
+		t.Errorf("Expected the hand to be in the deck still, got %v", err)
+	}
+}
+
+func TestWorkerNeverPanics(t *testing.T) {
+	bad := []Game{
+		{Table: Board{MaskOf(Card{1, 'H'})}, Hands: []Hand{{Cards: MaskOf(Card{2, 'H'}, Card{3, 'H'})}}, Deck: createDeck()},
+		{Hands: []Hand{{Cards: MaskOf(Card{2, 'H'})}}, Deck: createDeck() &^ Card{2, 'H'}.mask()},
+		{Hands: []Hand{{Cards: MaskOf(Card{2, 'H'}, Card{3, 'H'})}}, Deck: createDeck()},
+	}
+	for _, game := range bad {
+		assertNoPanic(t, func() {
+			if result := playGame(game, rng, newGameBuffers(len(game.Hands))); result.Err == nil || result.Winners != nil {
+				t.Errorf("Expected the game to fail: %+v", result)
+			}
+		})
+	}
+
+	// The worker hands the failed games over like any other
+	tallies := make(chan simulationStats, 1)
+	casinoWorker(context.Background(), bad[0], 3, rng, tallies)
+	if stats := <-tallies; stats.Err == nil || stats.Dealt != 3 || stats.Games != 0 {
+		t.Errorf("Expected 3 failed games, got %+v", stats)
+	}
+}
+
+func TestMergeWorkerTallies(t *testing.T) {
+	game := mustGame(t, Config{Hands: []string{"AhKh", "QQ", "72o"}, HiLo: true})
+	single := newSimulationStats(len(game.Hands))
+	merged := newSimulationStats(len(game.Hands))
+	chunk := newSimulationStats(len(game.Hands))
+	for i := 0; i < 3000; i++ {
+		result := playGame(game, rng, newGameBuffers(len(game.Hands)))
+		single.register(result)
+		chunk.register(result)
+		if i%700 == 0 {
+			merged.merge(chunk)
+			chunk = newSimulationStats(len(game.Hands))
+		}
+	}
+	merged.merge(chunk)
+	if !reflect.DeepEqual(single, merged) {
+		t.Errorf("Expected the merged tallies to match\n%+v, got\n%+v", single, merged)
+	}
+}
+
+// Parses a list of cards into a mask
+func mustCards(t *testing.T, text string) CardMask {
+	cards, err := ParseCards(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return MaskOf(cards...)
+}
+
+// Lists every card of the deck but the given ones
+func deckExcept(t *testing.T, text string) string {
+	cards, err := ParseCards(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return (createDeck() &^ MaskOf(cards...)).String()
+}
+
+func TestCancelSimulation(t *testing.T) {
+	before := runtime.NumGoroutine()
+	config := Config{Hands: []string{"AhKh", "QQ+"}, Iterations: 1000000000, Workers: 4}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result, err := Simulate(ctx, config)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected the deadline to stop the run, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("The run took %v to stop", time.Since(start))
+	}
+	if !result.Interrupted || result.Iterations == 0 || result.Iterations >= config.Iterations {
+		t.Errorf("Expected partial results: %+v", result)
+	}
+	total := result.Players[0].Equity + result.Players[1].Equity
+	if total < 0.999999 || total > 1.000001 {
+		t.Error("Partial equities should still add up to the whole pot")
+	}
+
+	// Every worker and dealer goroutine should be gone
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if runtime.NumGoroutine() > before {
+		t.Errorf("%v goroutines left behind", runtime.NumGoroutine()-before)
+	}
+
+	// Enumerating the boards stops too
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	game := mustGame(t, Config{Hands: []string{"AhKh", "QsQd"}, Board: "7s8s2h"})
+	stats := enumerateGames(ctx, game, Config{Workers: 2})
+	if !stats.Interrupted || stats.Dealt == 990 {
+		t.Errorf("Expected the enumeration to stop early: %+v", stats)
+	}
+	// The boards played are only a sample of them, so the result isn't exact
+	report := newResult(game, stats, true, 0, 0)
+	if report.Exact || !report.Interrupted || report.Iterations != stats.Games {
+		t.Errorf("Expected a partial enumeration to be reported as a sample: %+v", report)
+	}
+
+	// Every board is played once, in a random order
+	boards := shuffledBoards(game.Deck, 2, 1)
+	seen := make(map[CardMask]bool)
+	inOrder := 0
+	index := 0
+	forEachCardCombination(game.Deck, 2, func(board CardMask) {
+		seen[boards[index]] = true
+		if boards[index] == board {
+			inOrder++
+		}
+		index++
+	})
+	if len(seen) != 990 || inOrder > 10 {
+		t.Errorf("Expected all 990 boards shuffled, got %v boards, %v in order", len(seen), inOrder)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	var updates []Progress
+	config := Config{
+		Hands:            []string{"AhKh", "QQ+"},
+		Iterations:       1000000000,
+		Workers:          2,
+		ProgressInterval: 10 * time.Millisecond,
+		Progress: func(progress Progress) {
+			updates = append(updates, progress)
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	Simulate(ctx, config)
+
+	if len(updates) < 2 {
+		t.Fatalf("Expected regular progress updates, got %v", len(updates))
+	}
+	last := updates[len(updates)-1]
+	if last.Games <= updates[0].Games || last.Total != config.Iterations || last.Rate <= 0 || last.Remaining <= 0 {
+		t.Errorf("Progress should move forward: %+v", last)
+	}
+	if len(last.Equities) != 2 || last.Equities[0] <= 0 || last.Equities[0]+last.Equities[1] < 0.999999 {
+		t.Errorf("Expected current equity estimates: %v", last.Equities)
+	}
+}
+
+func mustGame(t *testing.T, config Config) Game {
+	game, err := config.Game()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return game
+}
+
+func TestConfidenceInterval(t *testing.T) {
+	// A player who always wins has no variance left
+	sure := playerStats{Wins: 100, SplitPots: make([]int, 3)}
+	if sure.confidenceInterval(100) != 0 {
+		t.Errorf("Expected an empty interval, got %v", sure.confidenceInterval(100))
+	}
+
+	// A coin flip over 10000 games: 1.96 * sqrt(0.25 * 10000/9999 / 10000)
+	flip := playerStats{Wins: 5000, SplitPots: make([]int, 3)}
+	if interval := flip.confidenceInterval(10000); math.Abs(interval-0.0098) > 0.0001 {
+		t.Errorf("Unexpected interval for a coin flip: %v", interval)
+	}
+
+	// Always splitting the pot in two is a sure half of the pot
+	split := playerStats{SplitPots: []int{0, 0, 100}}
+	if split.equity(100) != 0.5 || split.confidenceInterval(100) > 1e-9 {
+		t.Errorf("Expected a sure half of the pot, got %v ± %v", split.equity(100), split.confidenceInterval(100))
+	}
+}
+
+func TestSimulateUntilConverged(t *testing.T) {
+	game := Game{Deck: createDeck()}
+	AddPlayer(&game, "As Ks")
+	AddPlayer(&game, "Qh Qd")
+
+	// A loose precision is reached after the first batch
+	result := simulateGames(context.Background(), game, Config{Workers: 4, Iterations: 1000000, Precision: 0.05, Seed: 1})
+	if result.Dealt != convergenceBatchSize || !result.converged(0.05) {
+		t.Errorf("Expected to stop after one batch, played %v games", result.Dealt)
+	}
+
+	// An impossible precision runs until the cap
+	result = simulateGames(context.Background(), game, Config{Workers: 4, Iterations: 25000, Precision: 0.00001, Seed: 1})
+	if result.Dealt != 25000 || result.converged(0.00001) {
+		t.Errorf("Expected to stop at the cap, played %v games", result.Dealt)
+	}
+
+	// Without a precision every game is played in one go
+	result = simulateGames(context.Background(), game, Config{Workers: 4, Iterations: 1234, Seed: 1})
+	if result.Games != 1234 {
+		t.Errorf("Expected 1234 games, played %v", result.Games)
+	}
+
+	// Every board gets played on the flop, so the equities are exact whatever the precision
+	config := Config{Hands: []string{"AhKh", "QsQd"}, Board: "7s8s2h", Precision: 0.001}
+	report, err := Simulate(context.Background(), config)
+	if err != nil || !report.Exact || !report.Converged || report.Iterations != 990 {
+		t.Errorf("Expected exact results known to within the precision: %+v %v", report, err)
+	}
+}
+
+func TestSeededSimulationIsReproducible(t *testing.T) {
+	game := Game{Deck: createDeck()}
+	AddPlayer(&game, "As Ks")
+	AddPlayer(&game, "QQ+, AKo")
+
+	first := simulateGames(context.Background(), game, Config{Workers: 4, Iterations: 20000, Seed: 42})
+	second := simulateGames(context.Background(), game, Config{Workers: 4, Iterations: 20000, Seed: 42})
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Runs with the same seed differ:\n%+v\n%+v", first, second)
+	}
+	other := simulateGames(context.Background(), game, Config{Workers: 4, Iterations: 20000, Seed: 43})
+	if reflect.DeepEqual(first, other) {
+		t.Error("Runs with different seeds should differ")
+	}
+}
+
+// Asserts that a function doesn't throw a panic
+func assertNoPanic(t *testing.T, f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("The code did panic")
+		}
+	}()
+	f()
+}
+
+// Equal tells whether a and b contain the same elements.
+// A nil argument is equivalent to an empty slice.
+func EqualInt8Slice(a, b []int8) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, v := range a {
+		if v != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal tells whether a and b contain the same elements.
+// A nil argument is equivalent to an empty slice.
+func EqualIntSlice(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, v := range a {
+		if v != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal tells whether a and b contain the same elements.
+// A nil argument is equivalent to an empty slice.
+func EqualCardSlice(a, b []Card) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, v := range a {
+		if v != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func cardSliceContainsSameCards(a, b []Card) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, v := range a {
+		found := false
+		for _, v2 := range b {
+			if v == v2 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// The combination checkers Evaluate replaced, kept as the oracle it is tested against
+
+type playerCombination struct {
+	CombinationID int8
+	Data          []int8
+	Kickers       []Card
+}
+
+// Type used for sorting a group of cards by the face numbers (From 2 to the Ace).
+type byNumber []Card
+
+// Len Sort interface
+func (a byNumber) Len() int {
+	return len(a)
+}
+
+// Less Sort interface
+func (a byNumber) Less(i, j int) bool {
+	// Ace is 1, so first check that.
+	if a[i].Number == 1 {
+		return false
+	} else if a[j].Number == 1 {
+		return true
+	}
+	// If there are no aces involved, do a simple comparison.
+	return int(a[i].Number) < int(a[j].Number)
+}
+
+// Swap Sort interface
+func (a byNumber) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+// find 2, 3, or 4 of the same numbers on a slice of cards
+func findMultipleSameNumbers(cards []Card, nr int) (map[int8]int8, bool) {
+	store := make(map[int8]int8)
+	for _, card := range cards {
+		store[card.Number]++
+	}
+	for i, count := range store {
+		if count != int8(nr) {
+			delete(store, i)
+		}
+	}
+	found := false
+	if len(store) > 0 {
+		found = true
+	}
+	return store, found
+}
+
+// Check if [nr] cards with the same value are in the input slice
+func checkMultiples(cards []Card, nr int, kickerNr int) (int8, []Card) {
+	var kickers []Card
+	values, found := findMultipleSameNumbers(cards, nr)
+	if !found {
+		return 0, kickers
+	}
+
+	// Only keep the highest pair
+	var max int8 = 0
+	for i, _ := range values {
+		if (i > max && max != 1) || i == 1 {
+			max = i
+		}
+	}
+
+	// Return kickers
+	for _, c := range cards {
+		if c.Number != max {
+			kickers = append(kickers, c)
+		}
+	}
+
+	// Order kickers descending
+	sort.Sort(sort.Reverse(byNumber(kickers)))
+
+	// Return the leftover cards
+	kickers = kickers[:kickerNr]
+	return max, kickers
+}
+
+// Tries to find two pairs
+func checkTwoPairs(cards []Card) ([]int8, []Card) {
+	kickerNr := len(cards) - 2
+	twoPairs := []int8{}
+	found, kickers := checkMultiples(cards, 2, kickerNr)
+	if found == 0 {
+		return twoPairs, kickers
+	}
+	secondFound, kickers := checkMultiples(kickers, 2, 1)
+	if secondFound == 0 {
+		return twoPairs, kickers
+	}
+	twoPairs = append(twoPairs, found, secondFound)
+	return twoPairs, kickers
+}
+
+func checkOnePair(cards []Card) (int8, []Card) {
+	result, kickers := checkMultiples(cards, 2, 3)
+	return result, kickers
+}
+
+func checkTrips(cards []Card) (int8, []Card) {
+	result, kickers := checkMultiples(cards, 3, 2)
+	return result, kickers
+}
+
+func checkPoker(cards []Card) (int8, []Card) {
+	result, kickers := checkMultiples(cards, 4, 1)
+	return result, kickers
+}
+
+func checkStraight(cards []Card) int8 {
+	store := make(map[int8]int8)
+	for _, card := range cards {
+		store[card.Number]++
+		if card.Number == 1 { // An ace also counts as last card
+			store[int8(14)]++
+		}
+	}
+
+	var consecutive, found int8 = 0, 0
+	for _, nr := range getAllNumbers(true) {
+		if _, ok := store[nr]; ok {
+			consecutive++
+			if consecutive >= 5 {
+				found = nr
+			}
+		} else {
+			consecutive = 0
+		}
+	}
+	return found
+}
+
+func checkFullHouse(cards []Card) []int8 {
+	// Keep all the other cards, the pair doesn't have to be among the highest ones
+	trips, kickers := checkMultiples(cards, 3, len(cards)-3)
+	if trips > 0 {
+		pair, _ := checkMultiples(kickers, 2, 0)
+		// A second set of trips also fills the house
+		secondTrips, _ := checkMultiples(kickers, 3, 0)
+		if highValue(secondTrips) > highValue(pair) {
+			pair = secondTrips
+		}
+		if pair > 0 {
+			return []int8{trips, pair}
+		}
+	}
+	return []int8{}
+}
+
+func checkStraightFlush(cards []Card) int8 {
+	// The straight has to be made from the cards of a single suit
+	store := make(map[char][]Card)
+	for _, card := range cards {
+		store[card.Suit] = append(store[card.Suit], card)
+	}
+	for _, suited := range store {
+		if len(suited) >= 5 {
+			return checkStraight(suited)
+		}
+	}
+	return 0
+}
+
+func checkFlush(cards []Card) []int8 {
+	store := make(map[char][]int8)
+	for _, card := range cards {
+		store[card.Suit] = append(store[card.Suit], card.Number)
+	}
+
+	var found []int8
+	for i, item := range store {
+		if len(item) >= 5 {
+			for _, nr := range store[i] {
+				found = append(found, nr)
+			}
+		}
+	}
+
+	if len(found) == 0 {
+		var emptyResult []int8
+		return emptyResult
+	}
+
+	// Sort ascending, but take into account the ace
+	sort.Slice(found, func(i, j int) bool {
+		return (found[i] < found[j] && found[i] > 1)
+	})
+	// Keep only the 5 highest ones
+	found = found[len(found)-5:]
+	return found
+}
+
+// Describes the best combination in a pool of 7 cards, with its data and kickers
+func getPlayerCombination(cards []Card) playerCombination {
+	var kickers []Card
+
+	foundInt := checkStraightFlush(cards)
+	if foundInt > 0 {
+		return playerCombination{categoryStraightFlush, []int8{foundInt}, kickers}
+	}
+	foundInt, kickers = checkPoker(cards)
+	if foundInt > 0 {
+		return playerCombination{categoryPoker, []int8{foundInt}, kickers}
+	}
+	foundSlice := checkFullHouse(cards)
+	if len(foundSlice) > 0 {
+		return playerCombination{categoryFullHouse, foundSlice, []Card{}}
+	}
+	foundSlice = checkFlush(cards)
+	if len(foundSlice) > 0 {
+		return playerCombination{categoryFlush, foundSlice, []Card{}}
+	}
+	foundInt = checkStraight(cards)
+	if foundInt > 0 {
+		return playerCombination{categoryStraight, []int8{foundInt}, []Card{}}
+	}
+	foundInt, kickers = checkTrips(cards)
+	if foundInt > 0 {
+		return playerCombination{categoryTrips, []int8{foundInt}, kickers}
+	}
+	foundSlice, kickers = checkTwoPairs(cards)
+	if len(foundSlice) == 2 {
+		return playerCombination{categoryTwoPairs, foundSlice, kickers}
+	}
+	foundInt, kickers = checkOnePair(cards)
+	if foundInt > 0 {
+		return playerCombination{categoryOnePair, []int8{foundInt}, kickers}
+	}
+	highCards := append([]Card{}, cards...)
+	sort.Sort(sort.Reverse(byNumber(highCards)))
+	return playerCombination{categoryHighCard, []int8{}, highCards[:5]}
+}
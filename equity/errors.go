@@ -0,0 +1,40 @@
+package equity
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The ways a spot can be invalid, check for them with errors.Is
+var (
+	ErrBoardSize     = errors.New("expected 0, 3, 4 or 5 community cards")
+	ErrHandSize      = errors.New("wrong number of hole cards")
+	ErrDuplicateCard = errors.New("card is dealt more than once")
+	ErrDeckSize      = errors.New("not enough cards left in the deck")
+	ErrEmptyRange    = errors.New("range has no hands left after removing the known cards")
+	ErrNoPlayers     = errors.New("at least one player is needed")
+	ErrIterations    = errors.New("at least one iteration is needed")
+	ErrRangeVariant  = errors.New("ranges only work in hold'em")
+	ErrCardNotInDeck = errors.New("card isn't part of the deck in this game")
+	ErrRanking       = errors.New("a hand ranking lists every hand category once")
+	ErrStreet        = errors.New("every player has to be on the same street")
+)
+
+// Points out the part of the input which makes a spot invalid
+type ValidationError struct {
+	// Like "player 1", "board" or "dead cards"
+	Field string
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Field, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func playerField(playerIndex int) string {
+	return fmt.Sprintf("player %v", playerIndex)
+}
@@ -0,0 +1,248 @@
+package equity
+
+import "math/bits"
+
+// Hand categories as stored in the top bits of a hand rank, a higher category is a better hand
+const (
+	rankHighCard uint32 = iota + 1
+	rankOnePair
+	rankTwoPairs
+	rankTrips
+	rankStraight
+	rankFlush
+	rankFullHouse
+	rankPoker
+	rankStraightFlush
+)
+
+// The category sits above five 4 bit tiebreakers
+const rankCategoryShift = 20
+
+// Face value of the ace when it plays high
+const aceHigh = 14
+
+// Face value the ace takes at the bottom of a straight, right below the lowest card of the deck
+const (
+	aceLow          = 1
+	shortDeckAceLow = 5
+)
+
+// Gets the combination ID (as in the category constants) of a hand rank
+func RankCategory(rank uint32) int8 {
+	return int8(rankStraightFlush + 1 - rank>>rankCategoryShift&0xf)
+}
+
+// Builds a hand rank out of a category and up to five tiebreaking face values, strongest first
+func packRank(category uint32, values ...int) uint32 {
+	rank := category << rankCategoryShift
+	for i, value := range values {
+		rank |= uint32(value) << (16 - 4*i)
+	}
+	return rank
+}
+
+// Fills n tiebreakers, starting at position, with the highest face values of the set
+func addKickers(rank uint32, values uint16, position int, n int) uint32 {
+	for value := aceHigh; value >= aceLow && n > 0; value-- {
+		if values&(1<<value) != 0 {
+			rank |= uint32(value) << (16 - 4*position)
+			position++
+			n--
+		}
+	}
+	return rank
+}
+
+func suitIndex(suit char) int {
+	switch suit {
+	case 'H':
+		return 0
+	case 'D':
+		return 1
+	case 'C':
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Face value with the ace counted as the highest card
+func highValue(number int8) int {
+	if number == 1 {
+		return aceHigh
+	}
+	return int(number)
+}
+
+// Finds the highest card of a straight in a set of face values (bit n set = value n is present)
+func highestStraight(values uint16, lowAce int) int {
+	if values&(1<<aceHigh) != 0 {
+		values |= 1 << lowAce // The ace also plays as the lowest card
+	}
+	for high := aceHigh; high >= 5; high-- {
+		run := uint16(0x1f) << (high - 4)
+		if values&run == run {
+			return high
+		}
+	}
+	return 0
+}
+
+// Gets the highest face value in the set, or 0 for an empty set
+func highestValue(values uint16) int {
+	if values == 0 {
+		return 0
+	}
+	return bits.Len16(values) - 1
+}
+
+// Evaluates the best 5 card hand out of 5 to 7 cards into a single rank.
+// Ranks are totally ordered, so the better hand always has the higher rank and equal hands have equal ranks.
+func Evaluate(cards CardMask) uint32 {
+	return evaluate(cards, aceLow)
+}
+
+// Evaluates a hand of the 36 card short deck, where the ace also plays below the 6 in A-6-7-8-9.
+// The rank follows the standard order, with the full house above the flush.
+func EvaluateShortDeck(cards CardMask) uint32 {
+	return evaluate(cards, shortDeckAceLow)
+}
+
+// Evaluates a hand with the ace counting as lowAce at the bottom of a straight
+func evaluate(cards CardMask, lowAce int) uint32 {
+	h, d, c, s := cards.suitValues(0), cards.suitValues(1), cards.suitValues(2), cards.suitValues(3)
+	values := h | d | c | s
+
+	flushSuit := -1
+	for suit := 0; suit < 4; suit++ {
+		if bits.OnesCount16(cards.suitValues(suit)) >= 5 {
+			flushSuit = suit
+		}
+	}
+	if flushSuit >= 0 {
+		if high := highestStraight(cards.suitValues(flushSuit), lowAce); high > 0 {
+			return packRank(rankStraightFlush, high)
+		}
+	}
+
+	rank := pairsRank(h, d, c, s)
+	if rank>>rankCategoryShift >= rankFullHouse {
+		return rank
+	}
+	if flushSuit >= 0 {
+		return addKickers(packRank(rankFlush), cards.suitValues(flushSuit), 0, 5)
+	}
+	if high := highestStraight(values, lowAce); high > 0 {
+		return packRank(rankStraight, high)
+	}
+	return rank
+}
+
+// Ranks the best hand made of pokers, trips and pairs, given the face values held in every suit
+func pairsRank(h, d, c, s uint16) uint32 {
+	values := h | d | c | s
+	// Face values held in all four suits, in at least three and in at least two of them
+	fours := h & d & c & s
+	threes := (h & d & c) | (h & d & s) | (h & c & s) | (d & c & s)
+	twos := (h & d) | (h & c) | (h & s) | (d & c) | (d & s) | (c & s)
+
+	// Find the highest poker, the two highest trips and the two highest pairs
+	poker := highestValue(fours)
+	trips := highestValue(threes &^ fours)
+	secondTrips := highestValue(threes &^ fours &^ (1 << trips))
+	pair := highestValue(twos &^ threes)
+	secondPair := highestValue(twos &^ threes &^ (1 << pair))
+
+	if poker > 0 {
+		return addKickers(packRank(rankPoker, poker), values&^(1<<poker), 1, 1)
+	}
+	if trips > 0 && (secondTrips > 0 || pair > 0) {
+		if secondTrips > pair {
+			pair = secondTrips
+		}
+		return packRank(rankFullHouse, trips, pair)
+	}
+	if trips > 0 {
+		return addKickers(packRank(rankTrips, trips), values&^(1<<trips), 1, 2)
+	}
+	if secondPair > 0 {
+		return addKickers(packRank(rankTwoPairs, pair, secondPair), values&^(1<<pair|1<<secondPair), 2, 1)
+	}
+	if pair > 0 {
+		return addKickers(packRank(rankOnePair, pair), values&^(1<<pair), 1, 3)
+	}
+	return addKickers(packRank(rankHighCard), values, 0, 5)
+}
+
+// Highest card a low hand can hold in eight or better
+const lowQualifier = 8
+
+// Evaluates the best eight or better low out of 5 to 7 cards: five different face values of 8 or below,
+// with the ace playing low and straights and flushes not counting against the hand.
+// The better low has the higher rank, 0 means the cards don't make a qualifying low.
+func EvaluateLow(cards CardMask) uint32 {
+	values := cards.suitValues(0) | cards.suitValues(1) | cards.suitValues(2) | cards.suitValues(3)
+	if values&(1<<aceHigh) != 0 {
+		values |= 1 << 1
+	}
+	// Take the five lowest face values, the lows are compared from their highest card down
+	var low [5]int
+	found := 0
+	for value := 1; value <= lowQualifier && found < 5; value++ {
+		if values&(1<<value) != 0 {
+			low[4-found] = value
+			found++
+		}
+	}
+	if found < 5 {
+		return 0
+	}
+	return 1<<rankCategoryShift - packRank(0, low[:]...)
+}
+
+// Turns a high hand rank around into a lowball rank, so the worse high hand is the better low.
+// The category stays where RankCategory finds it.
+func lowballRank(rank uint32) uint32 {
+	category := rank >> rankCategoryShift & 0xf
+	tiebreakers := rank & (1<<rankCategoryShift - 1)
+	return (rankStraightFlush+1-category)<<rankStrengthShift | category<<rankCategoryShift |
+		(1<<rankCategoryShift - 1 - tiebreakers)
+}
+
+// Finds the best low out of 5 to 7 cards, with the lowball rank of every 5 card hand
+func bestLowball(cards CardMask, rank func(CardMask) uint32) uint32 {
+	var bestRank uint32
+	forEachCardCombination(cards, 5, func(hand CardMask) {
+		if low := rank(hand); low > bestRank {
+			bestRank = low
+		}
+	})
+	return bestRank
+}
+
+// Evaluates the best deuce to seven low out of 5 to 7 cards: the ace only plays high
+// and straights and flushes count against the hand, making 7-5-4-3-2 the best low.
+// The better low has the higher rank, RankCategory gives the high hand it makes.
+func EvaluateDeuceToSeven(cards CardMask) uint32 {
+	return bestLowball(cards, func(hand CardMask) uint32 {
+		return lowballRank(evaluate(hand, aceHigh))
+	})
+}
+
+// Evaluates the best ace to five low out of 5 to 7 cards, as played in razz: the ace only plays low
+// and straights and flushes don't count, making A-2-3-4-5 the best low. Pairs count against the hand.
+// The better low has the higher rank, RankCategory gives the pairs it holds or high card.
+func EvaluateAceToFive(cards CardMask) uint32 {
+	return bestLowball(cards, func(hand CardMask) uint32 {
+		h, d, c, s := hand.suitValues(0), hand.suitValues(1), hand.suitValues(2), hand.suitValues(3)
+		return lowballRank(pairsRank(lowAceValues(h), lowAceValues(d), lowAceValues(c), lowAceValues(s)))
+	})
+}
+
+// Moves the ace from the top to the bottom of a set of face values
+func lowAceValues(values uint16) uint16 {
+	if values&(1<<aceHigh) != 0 {
+		values = values&^(1<<aceHigh) | 1<<aceLow
+	}
+	return values
+}
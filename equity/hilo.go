@@ -0,0 +1,94 @@
+package equity
+
+import "sort"
+
+// Marks the games where nobody made a qualifying low, so the high hand takes the whole pot
+const noLow = -1
+
+// How a player shared the pot in a split pot game
+type hiLoShare struct {
+	// Number of players splitting the high half with this player, 0 when the player didn't get any of it
+	High int
+	// Number of players splitting the low half with this player, 0 when the player didn't get any of it,
+	// or noLow when nobody made a qualifying low
+	Low int
+}
+
+// Part of the high half the player got
+func (s hiLoShare) high() float64 {
+	if s.High <= 0 {
+		return 0
+	}
+	return 1 / float64(s.High)
+}
+
+// Part of the low half the player got
+func (s hiLoShare) low() float64 {
+	if s.Low <= 0 {
+		return 0
+	}
+	return 1 / float64(s.Low)
+}
+
+// Part of the whole pot the player got
+func (s hiLoShare) pot() float64 {
+	if s.Low == noLow {
+		return s.high()
+	}
+	return (s.high() + s.low()) / 2
+}
+
+// Tells you if the player took the whole pot
+func (s hiLoShare) scoop() bool {
+	return s.High == 1 && (s.Low == 1 || s.Low == noLow)
+}
+
+// Works out how every player shared the pot of a split pot game
+func hiLoShares(result gameResult, players int) []hiLoShare {
+	shares := make([]hiLoShare, players)
+	for _, id := range result.Winners {
+		shares[id].High = len(result.Winners)
+	}
+	for id := range shares {
+		if result.LowWinners == nil {
+			shares[id].Low = noLow
+		} else if containsPlayer(result.LowWinners, id) {
+			shares[id].Low = len(result.LowWinners)
+		}
+	}
+	return shares
+}
+
+// Averages the part of the pot the player got over all the games, with the given measure of a share.
+// The shares are added up in a fixed order, so the same games always give the same result.
+func (p playerStats) averageHiLoShare(games int, measure func(hiLoShare) float64) float64 {
+	var shares []hiLoShare
+	for share := range p.HiLo {
+		shares = append(shares, share)
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].High != shares[j].High {
+			return shares[i].High < shares[j].High
+		}
+		return shares[i].Low < shares[j].Low
+	})
+	total := 0.0
+	for _, share := range shares {
+		total += float64(p.HiLo[share]) * measure(share)
+	}
+	return fraction(total, games)
+}
+
+// Share of the high halves this player is expected to take
+func (p playerStats) highEquity(games int) float64 {
+	return p.averageHiLoShare(games, hiLoShare.high)
+}
+
+// Share of the low halves this player is expected to take with a qualifying low
+func (p playerStats) lowEquity(games int) float64 {
+	return p.averageHiLoShare(games, hiLoShare.low)
+}
+
+func (p playerStats) scoopProbability(games int) float64 {
+	return fraction(float64(p.Scoops), games)
+}
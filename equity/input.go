@@ -0,0 +1,288 @@
+package equity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Formats a card in standard notation, like Ah or Td
+func (c Card) String() string {
+	return fmt.Sprintf("%c%v", rangeRanks[highValue(c.Number)-2], strings.ToLower(c.Suit.String()))
+}
+
+// Parses a single card, either in standard notation (Ah, Td, 9c) or with a numeric face value (1H, 10h, 13S)
+func ParseCard(token string) (Card, error) {
+	if len(token) < 2 {
+		return Card{}, fmt.Errorf("card %q needs a rank and a suit", token)
+	}
+	rankText, suitText := token[:len(token)-1], token[len(token)-1]
+	suit, err := parseRangeSuit(suitText)
+	if err != nil {
+		return Card{}, fmt.Errorf("card %q: %v", token, err)
+	}
+
+	if rankText[0] >= '0' && rankText[0] <= '9' && len(rankText) <= 2 {
+		number, err := strconv.Atoi(rankText)
+		if err != nil || number < 1 || number > 13 {
+			return Card{}, fmt.Errorf("card %q: face values go from 1 (Ace) to 13 (King)", token)
+		}
+		return Card{int8(number), suit}, nil
+	}
+	if len(rankText) != 1 {
+		return Card{}, fmt.Errorf("card %q: unknown rank %q", token, rankText)
+	}
+	rank, err := parseRangeRank(rankText[0])
+	if err != nil {
+		return Card{}, fmt.Errorf("card %q: %v", token, err)
+	}
+	return Card{rangeRankToNumber(rank), suit}, nil
+}
+
+// Parses a list of cards in any mix of notations, like "7s8s2h", "Ah Td 9c" or "13S 7S 1H".
+// A card can't be listed twice.
+func ParseCards(text string) ([]Card, error) {
+	var cards []Card
+	var seen CardMask
+	for i := 0; i < len(text); {
+		if text[i] == ' ' || text[i] == ',' || text[i] == '\t' {
+			i++
+			continue
+		}
+		// A card ends with its suit, numeric face values can take two digits
+		end := i + 1
+		for end < len(text) && end-i < 3 && text[end] >= '0' && text[end] <= '9' {
+			end++
+		}
+		if end >= len(text) {
+			return nil, fmt.Errorf("card %q is missing a suit", text[i:])
+		}
+		card, err := ParseCard(text[i : end+1])
+		if err != nil {
+			return nil, err
+		}
+		if seen.Contains(card) {
+			return nil, fmt.Errorf("%w: %v is listed twice", ErrDuplicateCard, card)
+		}
+		seen |= card.mask()
+		cards = append(cards, card)
+		i = end + 1
+	}
+	return cards, nil
+}
+
+// Parses what was entered for a player: the exact hole cards, or a range of hands in hold'em
+func parsePlayerInput(text string, variant Variant) (Range, error) {
+	if variant.isStud() {
+		hand, err := parseStudHand(text)
+		if err != nil {
+			return Range{}, err
+		}
+		return Range{[]RangeCombo{{hand, text, 1}}, text}, nil
+	}
+	cards, err := ParseCards(text)
+	if err == nil {
+		hand := Hand{Cards: MaskOf(cards...)}
+		err = variant.checkKnownCards(hand)
+		if err == nil {
+			return Range{[]RangeCombo{{hand, text, 1}}, text}, nil
+		}
+		// A list of specific combos like "AhKd, AsKs" reads as cards too, but it's a range
+		if variant.hasRanges() {
+			if r, rangeErr := ParseRange(text); rangeErr == nil {
+				return r, nil
+			}
+		}
+		return Range{}, err
+	}
+	if !variant.hasRanges() {
+		if _, rangeErr := ParseRange(text); rangeErr == nil {
+			return Range{}, fmt.Errorf("%w, %v needs the exact hole cards", ErrRangeVariant, variant)
+		}
+		return Range{}, err
+	}
+	return ParseRange(text)
+}
+
+// Takes the cards out of the deck, failing if any of them is already dealt or isn't played in the game
+func takeCardsFromDeck(cards CardMask, game *Game) error {
+	if missing := cards &^ game.Variant.deck(); missing != 0 {
+		return fmt.Errorf("%w: %v", ErrCardNotInDeck, missing.Cards()[0])
+	}
+	if dealt := cards &^ game.Deck; dealt != 0 {
+		return fmt.Errorf("%w: %v", ErrDuplicateCard, dealt.Cards()[0])
+	}
+	game.Deck &^= cards
+	return nil
+}
+
+// Starts a game of hold'em with a full deck, no players and nothing on the table
+func NewGame() Game {
+	return NewVariantGame(Holdem)
+}
+
+// Starts a game of the variant with its full deck, no players and nothing on the table
+func NewVariantGame(variant Variant) Game {
+	return Game{Variant: variant, Ranking: variant.ranking(), Deck: variant.deck()}
+}
+
+// Builds the game described by the config, any problem is reported as a *ValidationError
+func (c Config) Game() (Game, error) {
+	game := NewVariantGame(c.Variant)
+	game.HiLo = c.HiLo
+	game.Streets = c.Streets || c.StreetEquity
+	game.StreetEquity = c.StreetEquity
+	if c.HiLo && c.Variant.IsLowball() {
+		return game, &ValidationError{"hi/lo", fmt.Errorf("%v is already played for the low", c.Variant)}
+	}
+	if c.Ranking != nil && c.Variant.IsLowball() {
+		return game, &ValidationError{"ranking", fmt.Errorf("%v ranks lows, not hand categories", c.Variant)}
+	}
+	if c.Ranking != nil {
+		if err := c.Ranking.validate(); err != nil {
+			return game, &ValidationError{"ranking", err}
+		}
+		game.Ranking = c.Ranking
+	}
+	for playerIndex, text := range c.Hands {
+		if err := AddPlayer(&game, text); err != nil {
+			return game, &ValidationError{playerField(playerIndex), err}
+		}
+	}
+	if err := SetBoard(&game, c.Board); err != nil {
+		return game, &ValidationError{"board", err}
+	}
+	if err := SetDeadCards(&game, c.Dead); err != nil {
+		return game, &ValidationError{"dead cards", err}
+	}
+	return game, nil
+}
+
+// Cards which still have to come out of the deck in every game, for the board and the range players
+func (g Game) cardsNeeded() (int, error) {
+	status, err := g.Table.status()
+	if err != nil {
+		return 0, err
+	}
+	if !g.Variant.HasBoard() {
+		return privateCardsNeeded(g.Hands, g.Variant.holeCards()), nil
+	}
+	needed := communityCardsLeft(status)
+	for _, playerRange := range g.Ranges {
+		if playerRange != nil {
+			needed += 2
+		}
+	}
+	return needed, nil
+}
+
+// Checks the whole game before any of it is played, so the workers never run into invalid input
+func (g Game) validate() error {
+	if len(g.Hands) == 0 {
+		return &ValidationError{"players", ErrNoPlayers}
+	}
+	if len(g.Ranges) != len(g.Hands) {
+		return &ValidationError{"players", fmt.Errorf("%v ranges for %v hands", len(g.Ranges), len(g.Hands))}
+	}
+	needed, err := g.cardsNeeded()
+	if err != nil {
+		return &ValidationError{"board", err}
+	}
+	for playerIndex, hand := range g.Hands {
+		if g.Ranges[playerIndex] == nil {
+			if err := g.Variant.checkKnownCards(hand); err != nil {
+				return &ValidationError{playerField(playerIndex), err}
+			}
+		} else if !g.Variant.hasRanges() {
+			return &ValidationError{playerField(playerIndex), ErrRangeVariant}
+		}
+		if g.Ranges[playerIndex] != nil && g.Ranges[playerIndex].availableWeight(g.Deck) <= 0 {
+			return &ValidationError{playerField(playerIndex), ErrEmptyRange}
+		}
+	}
+	if g.Variant.isStud() {
+		for playerIndex, hand := range g.Hands {
+			if err := checkStudStreet(g.Hands[0], hand); err != nil {
+				return &ValidationError{playerField(playerIndex), err}
+			}
+		}
+	}
+	if !g.Variant.HasBoard() && g.Table.Cards != 0 {
+		return &ValidationError{"board", fmt.Errorf("%w, %v has no community cards", ErrBoardSize, g.Variant)}
+	}
+	if err := checkDeckHealth(g.Deck, g.Table.Cards, g.Dead, g.Hands); err != nil {
+		return &ValidationError{"deck", err}
+	}
+	if g.Deck.Count() < needed {
+		return &ValidationError{"deck", fmt.Errorf("%w, %v cards are needed", ErrDeckSize, needed)}
+	}
+	return nil
+}
+
+// Adds a player to the game, holding either a known hand or a range
+func AddPlayer(game *Game, text string) error {
+	playerRange, err := parsePlayerInput(text, game.Variant)
+	if err != nil {
+		return err
+	}
+	// Ranges holding a single combo are played as a known hand
+	if len(playerRange.Combos) == 1 {
+		hand := playerRange.Combos[0].Hand
+		if game.Variant.isStud() && len(game.Hands) > 0 {
+			if err := checkStudStreet(game.Hands[0], hand); err != nil {
+				return err
+			}
+		}
+		if err := takeCardsFromDeck(hand.Cards, game); err != nil {
+			return err
+		}
+		game.Hands = append(game.Hands, hand)
+		game.Ranges = append(game.Ranges, nil)
+		return nil
+	}
+	game.Hands = append(game.Hands, Hand{})
+	game.Ranges = append(game.Ranges, &playerRange)
+	return nil
+}
+
+// Puts the community cards on the table
+func SetBoard(game *Game, text string) error {
+	cards, err := ParseCards(text)
+	if err != nil {
+		return err
+	}
+	if len(cards) != 0 && len(cards) != 3 && len(cards) != 4 && len(cards) != 5 {
+		return fmt.Errorf("%w, got %v", ErrBoardSize, len(cards))
+	}
+	if len(cards) != 0 && game.Variant.boardCards() == 0 {
+		return fmt.Errorf("%w, %v has no community cards", ErrBoardSize, game.Variant)
+	}
+	board := MaskOf(cards...)
+	if err := takeCardsFromDeck(board, game); err != nil {
+		return err
+	}
+	game.Table.Cards = board
+	return nil
+}
+
+// Takes cards which are out of play out of the deck, without giving them to anyone.
+// Enough cards have to stay in the deck to finish the board and deal the range players.
+func SetDeadCards(game *Game, text string) error {
+	cards, err := ParseCards(text)
+	if err != nil {
+		return err
+	}
+	dead := MaskOf(cards...)
+	needed, err := game.cardsNeeded()
+	if err != nil {
+		return err
+	}
+	if game.Deck.Count()-dead.Count() < needed {
+		return fmt.Errorf("%w, %v cards are still needed after the dead cards", ErrDeckSize, needed)
+	}
+	if err := takeCardsFromDeck(dead, game); err != nil {
+		return err
+	}
+	game.Dead |= dead
+	return nil
+}
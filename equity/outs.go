@@ -0,0 +1,57 @@
+package equity
+
+// The cards which win the next street for a player, keyed by the combination ID (as in the category constants)
+// of the hand they make with it
+type playerOuts struct {
+	Groups map[int8]CardMask
+	// Cards giving the player a share of a split pot
+	Splits CardMask
+}
+
+// Total number of cards winning the next street outright
+func (o playerOuts) Count() int {
+	count := 0
+	for _, cards := range o.Groups {
+		count += cards.Count()
+	}
+	return count
+}
+
+// Outs only make sense with every hand known and a street still to come after the flop or the turn.
+// Split pots have no single winner to find the outs for.
+func hasOuts(game Game) bool {
+	status, err := game.Table.status()
+	if err != nil || (status != 1 && status != 2) || game.HiLo {
+		return false
+	}
+	for _, playerRange := range game.Ranges {
+		if playerRange != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Deals every card left in the deck as the next street and finds out who it makes the winner
+func findOuts(game Game) []playerOuts {
+	outs := make([]playerOuts, len(game.Hands))
+	for i := range outs {
+		outs[i].Groups = make(map[int8]CardMask)
+	}
+	for _, card := range game.Deck.singles() {
+		board := game.Table.Cards | card
+		var bestRank uint32
+		var winners []int
+		for playerIndex, hand := range game.Hands {
+			registerPlayerHand(playerIndex, game.evaluate(board, hand.Cards), &bestRank, &winners)
+		}
+		if len(winners) > 1 {
+			for _, id := range winners {
+				outs[id].Splits |= card
+			}
+			continue
+		}
+		outs[winners[0]].Groups[RankCategory(bestRank)] |= card
+	}
+	return outs
+}
@@ -0,0 +1,324 @@
+package equity
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Face values ordered from the weakest to the strongest, as used in range notation
+const rangeRanks = "23456789TJQKA"
+
+// A single hole card combination a range player might be holding
+type RangeCombo struct {
+	Hand   Hand
+	Class  string
+	Weight float64
+}
+
+// A weighted set of hole card combinations, e.g. "TT+, AKs, A2s-A5s, KQo"
+type Range struct {
+	Combos []RangeCombo
+	// The range as it was written
+	Notation string
+}
+
+// A group of starting hands like AKs, AKo or TT
+type handClass struct {
+	High   int // index into rangeRanks
+	Low    int
+	Suited byte // 's', 'o' or 0 for both
+}
+
+func (c handClass) isPair() bool {
+	return c.High == c.Low
+}
+
+func (c handClass) String() string {
+	label := string(rangeRanks[c.High]) + string(rangeRanks[c.Low])
+	if c.Suited != 0 {
+		label += string(c.Suited)
+	}
+	return label
+}
+
+// Translates a range rank index to the card number used in the engine (Ace=1)
+func rangeRankToNumber(rank int) int8 {
+	if rank == len(rangeRanks)-1 {
+		return 1
+	}
+	return int8(rank + 2)
+}
+
+func parseRangeRank(c byte) (int, error) {
+	rank := strings.IndexByte(rangeRanks, strings.ToUpper(string(c))[0])
+	if rank < 0 {
+		return 0, fmt.Errorf("unknown rank %q", c)
+	}
+	return rank, nil
+}
+
+func parseRangeSuit(c byte) (char, error) {
+	suit := char(strings.ToUpper(string(c))[0])
+	for _, s := range getAllSuits() {
+		if s == suit {
+			return suit, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown suit %q", c)
+}
+
+// Parses a hand class without modifiers, like AKs, AKo, AK or TT
+func parseHandClass(text string) (handClass, error) {
+	var class handClass
+	if len(text) != 2 && len(text) != 3 {
+		return class, fmt.Errorf("invalid hand class %q", text)
+	}
+	first, err := parseRangeRank(text[0])
+	if err != nil {
+		return class, err
+	}
+	second, err := parseRangeRank(text[1])
+	if err != nil {
+		return class, err
+	}
+	class.High, class.Low = first, second
+	if second > first {
+		class.High, class.Low = second, first
+	}
+	if len(text) == 3 {
+		class.Suited = strings.ToLower(text[2:])[0]
+		if class.Suited != 's' && class.Suited != 'o' {
+			return class, fmt.Errorf("invalid suitedness in %q", text)
+		}
+		if class.isPair() {
+			return class, fmt.Errorf("pairs can't be suited or offsuit: %q", text)
+		}
+	}
+	return class, nil
+}
+
+// Expands a single comma separated part of a range into hand classes
+func parseRangeToken(token string) ([]handClass, error) {
+	if dash := strings.Index(token, "-"); dash >= 0 {
+		from, err := parseHandClass(token[:dash])
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseHandClass(token[dash+1:])
+		if err != nil {
+			return nil, err
+		}
+		if from.isPair() != to.isPair() || from.Suited != to.Suited || (!from.isPair() && from.High != to.High) {
+			return nil, fmt.Errorf("range bounds don't match in %q", token)
+		}
+		if from.Low > to.Low {
+			from, to = to, from
+		}
+		var classes []handClass
+		for low := from.Low; low <= to.Low; low++ {
+			class := handClass{from.High, low, from.Suited}
+			if from.isPair() {
+				class.High = low
+			}
+			classes = append(classes, class)
+		}
+		return classes, nil
+	}
+
+	if strings.HasSuffix(token, "+") {
+		base, err := parseHandClass(strings.TrimSuffix(token, "+"))
+		if err != nil {
+			return nil, err
+		}
+		var classes []handClass
+		if base.isPair() {
+			for rank := base.Low; rank < len(rangeRanks); rank++ {
+				classes = append(classes, handClass{rank, rank, 0})
+			}
+			return classes, nil
+		}
+		for low := base.Low; low < base.High; low++ {
+			classes = append(classes, handClass{base.High, low, base.Suited})
+		}
+		return classes, nil
+	}
+
+	class, err := parseHandClass(token)
+	if err != nil {
+		return nil, err
+	}
+	return []handClass{class}, nil
+}
+
+// Lists every suit combination belonging to a hand class
+func expandHandClass(class handClass) []Hand {
+	var hands []Hand
+	suits := getAllSuits()
+	for i, s1 := range suits {
+		for j, s2 := range suits {
+			if class.isPair() && j <= i {
+				continue
+			}
+			if (class.Suited == 's' && s1 != s2) || (class.Suited == 'o' && s1 == s2) {
+				continue
+			}
+			hands = append(hands, Hand{Cards: MaskOf(
+				Card{rangeRankToNumber(class.High), s1},
+				Card{rangeRankToNumber(class.Low), s2},
+			)})
+		}
+	}
+	return hands
+}
+
+// Parses a specific combo like AhKd
+func parseRangeCombo(token string) (Hand, error) {
+	var cards [2]Card
+	for i := range cards {
+		rank, err := parseRangeRank(token[i*2])
+		if err != nil {
+			return Hand{}, err
+		}
+		suit, err := parseRangeSuit(token[i*2+1])
+		if err != nil {
+			return Hand{}, err
+		}
+		cards[i] = Card{rangeRankToNumber(rank), suit}
+	}
+	if cards[0] == cards[1] {
+		return Hand{}, fmt.Errorf("%w: combo %q uses the same card twice", ErrDuplicateCard, token)
+	}
+	return Hand{Cards: MaskOf(cards[:]...)}, nil
+}
+
+// Splits the weight off a range part like "AKs:0.5", parts without a weight are always played
+func parseRangeWeight(token string) (string, float64, error) {
+	colon := strings.Index(token, ":")
+	if colon < 0 {
+		return token, 1, nil
+	}
+	weight, err := strconv.ParseFloat(token[colon+1:], 64)
+	if err != nil || weight < 0 || weight > 1 {
+		return token, 0, fmt.Errorf("invalid weight in %q, expected a frequency between 0 and 1", token)
+	}
+	return token[:colon], weight, nil
+}
+
+// Parses range notation like "TT+, AKs:0.5, A2s-A5s, KQo, AhKd" into a set of combos.
+// Every combo carries the frequency it is played with, a combo listed twice keeps its first weight.
+func ParseRange(text string) (Range, error) {
+	r := Range{Notation: strings.TrimSpace(text)}
+	seen := make(map[Hand]bool)
+	addCombo := func(hand Hand, class string, weight float64) {
+		if seen[hand] {
+			return
+		}
+		seen[hand] = true
+		r.Combos = append(r.Combos, RangeCombo{hand, class, weight})
+	}
+
+	for _, token := range strings.Split(text, ",") {
+		token = strings.ReplaceAll(token, " ", "")
+		if token == "" {
+			continue
+		}
+		token, weight, err := parseRangeWeight(token)
+		if err != nil {
+			return r, err
+		}
+		if len(token) == 4 && !strings.ContainsAny(token, "+-") {
+			hand, err := parseRangeCombo(token)
+			if err != nil {
+				return r, err
+			}
+			addCombo(hand, token, weight)
+			continue
+		}
+		classes, err := parseRangeToken(token)
+		if err != nil {
+			return r, err
+		}
+		for _, class := range classes {
+			for _, hand := range expandHandClass(class) {
+				addCombo(hand, class.String(), weight)
+			}
+		}
+	}
+
+	totalWeight := 0.0
+	for _, combo := range r.Combos {
+		totalWeight += combo.Weight
+	}
+	if totalWeight == 0 {
+		return r, fmt.Errorf("range %q is empty", text)
+	}
+	return r, nil
+}
+
+// Total weight of the combos which can still be dealt from the deck
+func (r Range) availableWeight(deck CardMask) float64 {
+	totalWeight := 0.0
+	for _, combo := range r.Combos {
+		if combo.Hand.Cards&deck == combo.Hand.Cards {
+			totalWeight += combo.Weight
+		}
+	}
+	return totalWeight
+}
+
+// Picks a random combo from the range, skipping combos holding cards which are no longer in the deck.
+// The remaining combos are picked in proportion to their weights.
+func (r Range) dealHand(deck CardMask, rng *rand.Rand) (RangeCombo, bool) {
+	totalWeight := r.availableWeight(deck)
+	if totalWeight <= 0 {
+		return RangeCombo{}, false
+	}
+
+	pick := rng.Float64() * totalWeight
+	var last RangeCombo
+	for _, combo := range r.Combos {
+		if combo.Hand.Cards&deck != combo.Hand.Cards {
+			continue
+		}
+		pick -= combo.Weight
+		last = combo
+		if pick < 0 {
+			break
+		}
+	}
+	return last, true
+}
+
+// Attempts at dealing every range player a hand before the game is given up
+const rangeDealAttempts = 1000
+
+// Deals a combo to every player holding a range and takes those cards out of the deck.
+// Players are dealt independently and the deal is repeated when two of them would hold the same card.
+func dealRangeHands(ranges []*Range, dealt []RangeCombo, deck *CardMask, rng *rand.Rand) bool {
+	for attempt := 0; attempt < rangeDealAttempts; attempt++ {
+		var used CardMask
+		clash := false
+		for playerIndex, playerRange := range ranges {
+			if playerRange == nil {
+				continue
+			}
+			combo, ok := playerRange.dealHand(*deck, rng)
+			if !ok {
+				return false
+			}
+			if used&combo.Hand.Cards != 0 {
+				clash = true
+			}
+			used |= combo.Hand.Cards
+			dealt[playerIndex] = combo
+		}
+		if clash {
+			continue
+		}
+		*deck &^= used
+		return true
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package equity
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The hand categories as combination IDs (as in the category constants), from the strongest to the weakest
+type HandRanking []int8
+
+// The usual order, the full house beats the flush
+var StandardRanking = HandRanking{1, 2, 3, 4, 5, 6, 7, 8, 9}
+
+// Short deck order, with fewer cards of every suit in the deck the flush beats the full house
+var ShortDeckRanking = HandRanking{1, 2, 4, 3, 5, 6, 7, 8, 9}
+
+// The strength of a category sits above the category itself
+const rankStrengthShift = 24
+
+// Parses a comma separated list of hand categories, strongest first,
+// like "straight flush, poker, flush, full house, straight, trips, two pairs, one pair, high card"
+func ParseHandRanking(text string) (HandRanking, error) {
+	var ranking HandRanking
+	for _, part := range strings.Split(text, ",") {
+		name := strings.ToLower(strings.Join(strings.Fields(part), ""))
+		category := int8(0)
+		for _, id := range StandardRanking {
+			if strings.ToLower(strings.ReplaceAll(CombinationName(id), " ", "")) == name {
+				category = id
+			}
+		}
+		if category == 0 {
+			return nil, fmt.Errorf("unknown hand category %q", strings.TrimSpace(part))
+		}
+		ranking = append(ranking, category)
+	}
+	return ranking, ranking.validate()
+}
+
+// Checks every hand category is listed exactly once
+func (r HandRanking) validate() error {
+	if len(r) != len(StandardRanking) {
+		return fmt.Errorf("%w, got %v categories", ErrRanking, len(r))
+	}
+	seen := make(map[int8]bool)
+	for _, category := range r {
+		if category < 1 || int(category) > len(StandardRanking) {
+			return fmt.Errorf("%w, %v isn't a hand category", ErrRanking, category)
+		}
+		if seen[category] {
+			return fmt.Errorf("%w, %v is listed twice", ErrRanking, CombinationName(category))
+		}
+		seen[category] = true
+	}
+	return nil
+}
+
+func (r HandRanking) String() string {
+	names := make([]string, len(r))
+	for i, category := range r {
+		names[i] = CombinationName(category)
+	}
+	return strings.Join(names, " > ")
+}
+
+// Tells you if the ranking is the usual order
+func (r HandRanking) isStandard() bool {
+	return r.sameOrder(StandardRanking)
+}
+
+// Tells you if both rankings list the categories in the same order, a nil ranking is in any order
+func (r HandRanking) sameOrder(other HandRanking) bool {
+	for i, category := range r {
+		if category != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Tells you if the best category of up to 7 cards in the usual order is also their best in this ranking.
+// A flush and a full house never come out of the same 7 cards, so the short deck order keeps it.
+func (r HandRanking) keepsBestCategory() bool {
+	return r.isStandard() || r.sameOrder(ShortDeckRanking)
+}
+
+// The order of the hand categories in the game, the usual order unless it was set
+func (g Game) ranking() HandRanking {
+	if g.Ranking == nil {
+		return StandardRanking
+	}
+	return g.Ranking
+}
+
+// Puts the strength of the rank's category in this ranking on top of the rank, so ranks compare in this order.
+// A nil ranking keeps the usual order.
+func (r HandRanking) apply(rank uint32) uint32 {
+	if r == nil || rank == 0 {
+		return rank
+	}
+	category := RankCategory(rank)
+	for i, id := range r {
+		if id == category {
+			return uint32(len(r)-i)<<rankStrengthShift | rank
+		}
+	}
+	return rank
+}
@@ -0,0 +1,272 @@
+package equity
+
+import "time"
+
+// Outcome of a whole simulation, ready to be exported as JSON
+type Result struct {
+	Game string `json:"game"`
+	// Set when the hand categories aren't in the usual order, strongest first
+	Ranking    []string `json:"ranking,omitempty"`
+	Board      string   `json:"board"`
+	Dead       string   `json:"dead,omitempty"`
+	Exact      bool     `json:"exact"`
+	Iterations int      `json:"iterations"`
+	// Set when the simulation ran until the equities were known to within this precision
+	TargetPrecision float64 `json:"target_precision,omitempty"`
+	Converged       bool    `json:"converged,omitempty"`
+	// Set when the simulation was stopped early, the results only cover the games played until then
+	Interrupted  bool  `json:"interrupted,omitempty"`
+	Seed         int64 `json:"seed"`
+	SkippedGames int   `json:"skipped_games"`
+	// Set when the pots are split between high and low
+	HiLo           *HiLoReport    `json:"hi_lo,omitempty"`
+	Players        []PlayerReport `json:"players"`
+	Splits         SplitReport    `json:"splits"`
+	ElapsedSeconds float64        `json:"elapsed_seconds"`
+}
+
+type PlayerReport struct {
+	Player int `json:"player"`
+	// The known hole cards, or the range the hand was dealt from
+	Hand   string  `json:"hand"`
+	Win    float64 `json:"win"`
+	Tie    float64 `json:"tie"`
+	Equity float64 `json:"equity"`
+	// Half width of the 95% confidence interval around the equity, 0 for exact results
+	EquityCI95     float64        `json:"equity_ci95"`
+	SampledClasses map[string]int `json:"sampled_classes,omitempty"`
+	// The hand categories the player ended up with, strongest first
+	Categories []CategoryReport `json:"categories"`
+	// Set on the flop and the turn when every hand is known
+	Outs *OutsReport `json:"outs,omitempty"`
+	// How the player stood after every street which was dealt in the simulation
+	Streets []StreetReport `json:"streets,omitempty"`
+	// Set when the pots are split between high and low, Win and Tie are then about the high hand
+	HiLo *PlayerHiLoReport `json:"hi_lo,omitempty"`
+}
+
+// How the split pot games turned out
+type HiLoReport struct {
+	// Share of the games where nobody made a qualifying low, so the high hand took the whole pot
+	NoLow float64 `json:"no_low"`
+}
+
+// How a player did in split pot games
+type PlayerHiLoReport struct {
+	// Share of the high halves and of the low halves the player is expected to take
+	HighEquity float64 `json:"high_equity"`
+	LowEquity  float64 `json:"low_equity"`
+	// Chance the player takes the whole pot
+	Scoop float64 `json:"scoop"`
+}
+
+// How a player stood after a street
+type StreetReport struct {
+	Street string `json:"street"`
+	// Set when the street equity is worked out
+	Equity *StreetEquityReport `json:"equity,omitempty"`
+	Leads  LeadReport          `json:"leads"`
+}
+
+// The players equity after a street
+type StreetEquityReport struct {
+	// Averaged over all games
+	Mean float64 `json:"mean"`
+	// Share of the games by the equity after the street, in tenths from 0-10% up to 90-100%
+	Distribution []float64 `json:"distribution"`
+}
+
+// How often a player held the best hand after a street and how that lead held up, as a share of all games
+type LeadReport struct {
+	Ahead        float64 `json:"ahead"`
+	AheadAndWon  float64 `json:"ahead_and_won"`
+	AheadButLost float64 `json:"ahead_but_lost"`
+	BehindButWon float64 `json:"behind_but_won"`
+}
+
+// The cards which make a player the winner on the next street
+type OutsReport struct {
+	// Either turn or river
+	Street string `json:"street"`
+	Count  int    `json:"count"`
+	// Chance the next card makes the player the winner
+	Win float64 `json:"win"`
+	// Cards giving the player a share of a split pot
+	Splits string            `json:"splits"`
+	Groups []OutsGroupReport `json:"groups"`
+}
+
+// Outs making the same hand category
+type OutsGroupReport struct {
+	Category string `json:"category"`
+	Cards    string `json:"cards"`
+	Count    int    `json:"count"`
+}
+
+// How often a player ended up with a hand category, and how they did with it
+type CategoryReport struct {
+	Category  string  `json:"category"`
+	Games     int     `json:"games"`
+	Frequency float64 `json:"frequency"`
+	// Chance to win or tie given the player ended up with this category
+	Win float64 `json:"win"`
+	Tie float64 `json:"tie"`
+}
+
+type SplitReport struct {
+	Games       int     `json:"games"`
+	Probability float64 `json:"probability"`
+	// Split games keyed by the number of players sharing the pot
+	ByPlayers map[int]int `json:"by_players"`
+}
+
+// Collects the results of the played games into a report
+func newResult(game Game, result simulationStats, exact bool, precision float64,
+	elapsed time.Duration) Result {
+	games := result.Games
+	// An enumeration stopped early only played a random sample of the boards
+	exact = exact && !result.Interrupted
+	report := Result{
+		Game:            game.Variant.String(),
+		Board:           game.Table.Cards.String(),
+		Dead:            game.Dead.String(),
+		Exact:           exact,
+		Iterations:      games,
+		TargetPrecision: precision,
+		Interrupted:     result.Interrupted,
+		SkippedGames:    result.Dealt - games,
+		ElapsedSeconds:  elapsed.Seconds(),
+		Splits:          SplitReport{ByPlayers: result.SplitWays},
+	}
+	if precision > 0 {
+		// Playing every board gives the exact equities, which are known to within any precision
+		report.Converged = exact || result.converged(precision)
+	}
+	if game.Ranking != nil && !game.Ranking.isStandard() {
+		for _, category := range game.Ranking {
+			report.Ranking = append(report.Ranking, CombinationName(category))
+		}
+	}
+	if game.HiLo {
+		report.HiLo = &HiLoReport{NoLow: fraction(float64(result.NoLowGames), games)}
+	}
+	for _, count := range result.SplitWays {
+		report.Splits.Games += count
+	}
+	report.Splits.Probability = fraction(float64(report.Splits.Games), games)
+
+	var outs []playerOuts
+	if hasOuts(game) {
+		outs = findOuts(game)
+	}
+	for i, player := range result.Stats {
+		interval := player.confidenceInterval(games)
+		if exact {
+			interval = 0
+		}
+		hand := game.Hands[i].String()
+		if game.Ranges[i] != nil {
+			hand = game.Ranges[i].Notation
+		}
+		report.Players = append(report.Players, PlayerReport{
+			Player:         i,
+			Hand:           hand,
+			Win:            player.winProbability(games),
+			Tie:            player.tieProbability(games),
+			Equity:         player.equity(games),
+			EquityCI95:     interval,
+			SampledClasses: player.SampledClasses,
+			Categories:     player.categoryReports(games, game.ranking()),
+			Streets:        player.streetReports(games),
+		})
+		if game.HiLo {
+			report.Players[i].HiLo = &PlayerHiLoReport{
+				HighEquity: player.highEquity(games),
+				LowEquity:  player.lowEquity(games),
+				Scoop:      player.scoopProbability(games),
+			}
+		}
+		if outs != nil {
+			report.Players[i].Outs = newOutsReport(game, outs[i])
+		}
+	}
+	return report
+}
+
+// Part of the total the count makes up, 0 when there was nothing to count
+func fraction(count float64, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return count / float64(total)
+}
+
+// Lists a players outs grouped by hand category, strongest first
+func newOutsReport(game Game, outs playerOuts) *OutsReport {
+	// Outs are only found on the flop and the turn
+	status, _ := game.Table.status()
+	report := &OutsReport{
+		Street: getStreetName(status + 1),
+		Count:  outs.Count(),
+		Win:    float64(outs.Count()) / float64(game.Deck.Count()),
+		Splits: outs.Splits.String(),
+	}
+	for _, category := range game.ranking() {
+		if cards, ok := outs.Groups[category]; ok {
+			report.Groups = append(report.Groups, OutsGroupReport{CombinationName(category), cards.String(), cards.Count()})
+		}
+	}
+	return report
+}
+
+// Lists the hand classes dealt to a range player, the most frequent first
+func (p PlayerReport) SortedClasses() []string {
+	return playerStats{SampledClasses: p.SampledClasses}.sortedClasses()
+}
+
+// Lists the hand categories the player ended up with, strongest first
+func (p playerStats) categoryReports(games int, ranking HandRanking) []CategoryReport {
+	var reports []CategoryReport
+	for _, category := range ranking {
+		categoryStats, ok := p.Categories[category]
+		if !ok {
+			continue
+		}
+		reports = append(reports, CategoryReport{
+			Category:  CombinationName(category),
+			Games:     categoryStats.Games,
+			Frequency: fraction(float64(categoryStats.Games), games),
+			Win:       fraction(float64(categoryStats.Wins), categoryStats.Games),
+			Tie:       fraction(float64(categoryStats.Ties), categoryStats.Games),
+		})
+	}
+	return reports
+}
+
+// Lists how the player stood after the flop and the turn, for the streets dealt in the simulation
+func (p playerStats) streetReports(games int) []StreetReport {
+	var reports []StreetReport
+	for status := 1; status < 3; status++ {
+		streetStats, ok := p.Streets[status]
+		if !ok {
+			continue
+		}
+		report := StreetReport{
+			Street: getStreetName(status),
+			Leads: LeadReport{
+				Ahead:        fraction(float64(streetStats.Ahead), games),
+				AheadAndWon:  fraction(float64(streetStats.AheadAndWon), games),
+				AheadButLost: fraction(float64(streetStats.Ahead-streetStats.AheadAndWon), games),
+				BehindButWon: fraction(float64(streetStats.BehindButWon), games),
+			},
+		}
+		if streetStats.Runouts.Runouts > 0 {
+			report.Equity = &StreetEquityReport{Mean: streetStats.Runouts.equity()}
+			for _, count := range streetStats.Equities {
+				report.Equity.Distribution = append(report.Equity.Distribution, fraction(float64(count), games))
+			}
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
@@ -0,0 +1,392 @@
+package equity
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Games dealt between two checks of the confidence intervals
+const convergenceBatchSize = 10000
+
+// Describes the spot to simulate
+type Config struct {
+	// The poker game, hold'em unless set
+	Variant Variant
+	// Known hole cards (AhKh, or 4 or 5 cards in Omaha) or a hold'em range (TT+, AKs:0.5) for every player
+	Hands []string
+	// Community cards on the table, none or 3 to 5 of them
+	Board string
+	// Cards out of play which nobody can be dealt
+	Dead string
+	// Games to simulate, or the most games to play when a precision is set.
+	// Ignored when there are few enough boards left to play every one of them.
+	Iterations int
+	// Number of goroutines to use, runtime.NumCPU() when 0
+	Workers int
+	// When above 0, games are played until every equity is known to within this precision
+	Precision float64
+	// Splits every pot between the best high and the best eight or better low
+	HiLo bool
+	// The order of the hand categories, strongest first. The order of the variant when nil.
+	Ranking HandRanking
+	// Reports who holds the best hand after the flop and the turn and how that lead held up.
+	// Off by default, it takes an extra evaluation of every hand on every street.
+	Streets bool
+	// Works out every players equity after the flop and the turn too, not in split pot games.
+	// Every board left is played out in every game, which makes games dealt before the flop a lot slower.
+	StreetEquity bool
+	// Seeds the random sources of the workers, the same seed, iterations and workers give the same results
+	Seed int64
+	// Called every ProgressInterval (a quarter second when 0) from the goroutine running Simulate
+	Progress         func(Progress)
+	ProgressInterval time.Duration
+}
+
+// A snapshot of a running simulation
+type Progress struct {
+	Games int
+	// The most games the simulation will play, a precision can make it stop sooner
+	Total   int
+	Elapsed time.Duration
+	// Games played per second
+	Rate float64
+	// Time left until every game is played
+	Remaining time.Duration
+	// Current equity estimate of every player
+	Equities []float64
+}
+
+// Calls the progress callback of a config every interval while the results come in
+type progressReporter struct {
+	callback func(Progress)
+	ticker   *time.Ticker
+	// Never fires without a callback
+	ticks <-chan time.Time
+	start time.Time
+	total int
+}
+
+func newProgressReporter(config Config, total int) *progressReporter {
+	reporter := &progressReporter{callback: config.Progress, start: time.Now(), total: total}
+	if config.Progress != nil {
+		interval := config.ProgressInterval
+		if interval <= 0 {
+			interval = 250 * time.Millisecond
+		}
+		reporter.ticker = time.NewTicker(interval)
+		reporter.ticks = reporter.ticker.C
+	}
+	return reporter
+}
+
+func (p *progressReporter) report(stats simulationStats) {
+	elapsed := time.Since(p.start)
+	progress := Progress{Games: stats.Dealt, Total: p.total, Elapsed: elapsed}
+	if elapsed > 0 {
+		progress.Rate = float64(stats.Dealt) / elapsed.Seconds()
+	}
+	if progress.Rate > 0 {
+		progress.Remaining = time.Duration(float64(p.total-stats.Dealt) / progress.Rate * float64(time.Second))
+	}
+	for _, player := range stats.Stats {
+		equity := 0.0
+		if stats.Games > 0 {
+			equity = player.equity(stats.Games)
+		}
+		progress.Equities = append(progress.Equities, equity)
+	}
+	p.callback(progress)
+}
+
+func (p *progressReporter) stop() {
+	if p.ticker != nil {
+		p.ticker.Stop()
+	}
+}
+
+// Tells you if every possible board gets played instead of sampling random ones
+func (c Config) Exact() bool {
+	game, err := c.Game()
+	return err == nil && game.exact()
+}
+
+// Tells you if there are few enough boards left to play every one of them.
+// Players with a range and games where every player gets their own cards always need sampling.
+func (g Game) exact() bool {
+	if !g.Variant.HasBoard() {
+		return false
+	}
+	for _, playerRange := range g.Ranges {
+		if playerRange != nil {
+			return false
+		}
+	}
+	status, err := g.Table.status()
+	if err != nil {
+		return false
+	}
+	return countCardCombinations(g.Deck.Count(), communityCardsLeft(status)) <= exactEnumerationThreshold
+}
+
+// Plays the spot described by the config and reports how every player did.
+// Invalid input is reported as a *ValidationError before any game is played,
+// or after all of them were skipped when the ranges never leave a hand for every player.
+// When the context is done before the end, the games played so far are reported along with the context error.
+func Simulate(ctx context.Context, config Config) (Result, error) {
+	game, err := config.Game()
+	if err != nil {
+		return Result{}, err
+	}
+	if err := game.validate(); err != nil {
+		return Result{}, err
+	}
+	exact := game.exact()
+	if !exact && config.Iterations < 1 {
+		return Result{}, &ValidationError{"iterations", ErrIterations}
+	}
+	if config.Workers <= 0 {
+		config.Workers = runtime.NumCPU()
+	}
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	start := time.Now()
+	var stats simulationStats
+	if exact {
+		stats = enumerateGames(ctx, game, config)
+	} else {
+		stats = simulateGames(ctx, game, config)
+	}
+	if stats.Err != nil {
+		return Result{}, stats.Err
+	}
+	if stats.Interrupted && stats.Games == 0 {
+		return Result{}, ctx.Err()
+	}
+	if stats.Games == 0 {
+		// The ranges always took the same cards, so every dealt game had to be skipped
+		return Result{}, &ValidationError{"ranges", fmt.Errorf("%w, none of the %v dealt games had a hand for every player",
+			ErrEmptyRange, stats.Dealt)}
+	}
+	result := newResult(game, stats, exact, config.Precision, time.Since(start))
+	result.Seed = config.Seed
+	if stats.Interrupted {
+		return result, ctx.Err()
+	}
+	return result, nil
+}
+
+// Tallies collected over all the played games
+type simulationStats struct {
+	Stats []playerStats
+	// Split games keyed by the number of players sharing the pot
+	SplitWays map[int]int
+	// Games that were played, and games that were dealt including the ones that had to be skipped
+	Games int
+	Dealt int
+	// Split pot games where nobody made a qualifying low
+	NoLowGames int
+	// The first game which couldn't be played
+	Err error
+	// Set when the context was done before every game was played
+	Interrupted bool
+}
+
+func newSimulationStats(players int) simulationStats {
+	return simulationStats{
+		Stats:     make([]playerStats, players),
+		SplitWays: make(map[int]int),
+	}
+}
+
+// Adds the outcome of a single game to the tallies
+func (r *simulationStats) register(result gameResult) {
+	r.Dealt++
+	if result.Err != nil && r.Err == nil {
+		r.Err = result.Err
+	}
+	if result.Winners == nil {
+		return
+	}
+	r.Games++
+	registerGameResult(result, r.Stats)
+	if result.HiLo && result.LowWinners == nil {
+		r.NoLowGames++
+	}
+	if len(result.Winners) > 1 {
+		r.SplitWays[len(result.Winners)]++
+	}
+}
+
+// Tells you if every players equity is known to within the precision
+func (r simulationStats) converged(precision float64) bool {
+	for _, player := range r.Stats {
+		if player.confidenceInterval(r.Games) > precision {
+			return false
+		}
+	}
+	return true
+}
+
+// Derives an independent random source for every worker out of a single seed
+func workerRandomSources(seed int64, workers int) []*rand.Rand {
+	seeds := rand.New(rand.NewSource(seed))
+	sources := make([]*rand.Rand, workers)
+	for i := range sources {
+		sources[i] = rand.New(rand.NewSource(seeds.Int63()))
+	}
+	return sources
+}
+
+// Adds the tallies a worker handed over
+func (r *simulationStats) merge(other simulationStats) {
+	for i := range r.Stats {
+		r.Stats[i].merge(other.Stats[i])
+	}
+	for ways, count := range other.SplitWays {
+		r.SplitWays[ways] += count
+	}
+	r.Games += other.Games
+	r.Dealt += other.Dealt
+	r.NoLowGames += other.NoLowGames
+	if r.Err == nil {
+		r.Err = other.Err
+	}
+}
+
+// Merges the tallies of the workers until n more games were dealt, reporting progress on the way.
+// Returns false when the context is done before all of them came in.
+func (r *simulationStats) collect(ctx context.Context, tallies <-chan simulationStats, n int, progress *progressReporter,
+	finished <-chan struct{}) bool {
+	for target := r.Dealt + n; r.Dealt < target; {
+		select {
+		case workerStats := <-tallies:
+			r.merge(workerStats)
+		case <-progress.ticks:
+			progress.report(*r)
+		case <-ctx.Done():
+			r.Interrupted = true
+			r.collectStopped(tallies, finished)
+			return false
+		}
+	}
+	return true
+}
+
+// Merges the tallies the workers hand over while they stop, until every one of them is done
+func (r *simulationStats) collectStopped(tallies <-chan simulationStats, finished <-chan struct{}) {
+	for {
+		select {
+		case workerStats := <-tallies:
+			r.merge(workerStats)
+		case <-finished:
+			// Every handover is done by now, only the ones still in the channel are left
+			for {
+				select {
+				case workerStats := <-tallies:
+					r.merge(workerStats)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Runs n workers, the returned channel is closed once all of them are done
+func startWorkers(n int, worker func(i int)) <-chan struct{} {
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			worker(i)
+		}(i)
+	}
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	return finished
+}
+
+// Plays every possible completion of the board, or as many as it can before the context is done
+func enumerateGames(ctx context.Context, game Game, config Config) simulationStats {
+	result := newSimulationStats(len(game.Hands))
+	// The game is validated, so the status is known
+	status, _ := game.Table.status()
+	boardCount := countCardCombinations(game.Deck.Count(), communityCardsLeft(status))
+	progress := newProgressReporter(config, boardCount)
+	defer progress.stop()
+	// Stops the workers once the results are in
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tallies := make(chan simulationStats, config.Workers)
+
+	// Every worker plays its own share of the boards
+	boards := shuffledBoards(game.Deck, communityCardsLeft(status), config.Seed)
+	sources := workerRandomSources(config.Seed, config.Workers)
+	finished := startWorkers(config.Workers, func(i int) {
+		enumerationWorker(ctx, game, boards, i, config.Workers, sources[i], tallies)
+	})
+
+	result.collect(ctx, tallies, boardCount, progress, finished)
+	return result
+}
+
+// Lists every way to complete the board out of the deck in a random order.
+// Boards are played in this order, so an enumeration stopped early has played a random sample of them.
+func shuffledBoards(deck CardMask, nr int, seed int64) []CardMask {
+	var boards []CardMask
+	forEachCardCombination(deck, nr, func(boardCards CardMask) {
+		boards = append(boards, boardCards)
+	})
+	rand.New(rand.NewSource(seed)).Shuffle(len(boards), func(i, j int) {
+		boards[i], boards[j] = boards[j], boards[i]
+	})
+	return boards
+}
+
+// Plays the configured number of random games. With a precision above 0 the games are played in batches,
+// stopping early once every players equity is known to within the precision.
+// Each worker gets a fixed share of every batch, so the same seed and number of workers always give the same result.
+// When the context is done, the games played so far are returned.
+func simulateGames(ctx context.Context, game Game, config Config) simulationStats {
+	result := newSimulationStats(len(game.Hands))
+	maxGames, workers := config.Iterations, config.Workers
+	progress := newProgressReporter(config, maxGames)
+	defer progress.stop()
+	// Stops the workers once the results are in
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tallies := make(chan simulationStats, workers)
+	sources := workerRandomSources(config.Seed, workers)
+
+	for result.Dealt < maxGames {
+		batch := maxGames - result.Dealt
+		if config.Precision > 0 && batch > convergenceBatchSize {
+			batch = convergenceBatchSize
+		}
+		// Every worker plays its share of the batch on the same scenario and hands over its own tallies
+		finished := startWorkers(workers, func(i int) {
+			share := batch / workers
+			if i < batch%workers {
+				share++
+			}
+			casinoWorker(ctx, game, share, sources[i], tallies)
+		})
+		if !result.collect(ctx, tallies, batch, progress, finished) {
+			break
+		}
+		if config.Precision > 0 && result.converged(config.Precision) {
+			break
+		}
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+package equity
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Cards every stud player ends up with, three down and four up
+const (
+	studCards     = 7
+	studUpCards   = 4
+	studDownCards = 3
+)
+
+// Parses a stud players known cards, the downcards and the upcards split by a slash.
+// Like "AhKh/Qs" on third street, or "/Qs9c" when the downcards aren't known.
+func parseStudHand(text string) (Hand, error) {
+	parts := strings.Split(text, "/")
+	if len(parts) != 2 {
+		return Hand{}, fmt.Errorf("stud hands are written as downcards/upcards, like AhKh/Qs, got %q", text)
+	}
+	down, err := ParseCards(parts[0])
+	if err != nil {
+		return Hand{}, err
+	}
+	up, err := ParseCards(parts[1])
+	if err != nil {
+		return Hand{}, err
+	}
+	hand := Hand{Cards: MaskOf(down...) | MaskOf(up...), Up: MaskOf(up...)}
+	if hand.Cards.Count() != len(down)+len(up) {
+		return Hand{}, fmt.Errorf("%w: %v is both down and up", ErrDuplicateCard, (MaskOf(down...) & hand.Up).Cards()[0])
+	}
+	return hand, checkStudHand(hand)
+}
+
+// Checks the known cards of a stud player fit a street, one to four upcards with at most two downcards
+// until the last one is dealt on seventh street
+func checkStudHand(hand Hand) error {
+	up, down := hand.Up.Count(), (hand.Cards &^ hand.Up).Count()
+	if up < 1 || up > studUpCards {
+		return fmt.Errorf("%w, stud needs 1 to %v upcards, got %v", ErrHandSize, studUpCards, up)
+	}
+	maxDown := studDownCards - 1
+	if up == studUpCards {
+		maxDown = studDownCards
+	}
+	if down > maxDown {
+		return fmt.Errorf("%w, stud has at most %v downcards with %v upcards, got %v", ErrHandSize, maxDown, up, down)
+	}
+	return nil
+}
+
+// Checks a stud player is on the same street as the first player, folded players are out of the game
+func checkStudStreet(first Hand, hand Hand) error {
+	if hand.Up.Count() != first.Up.Count() {
+		return fmt.Errorf("%w, player 0 has %v upcards, got %v", ErrStreet, first.Up.Count(), hand.Up.Count())
+	}
+	return nil
+}
+
+// Cards still to be dealt when every player gets n cards of their own, like the unknown downcards
+// and the streets to come in stud or the draw in a draw game
+func privateCardsNeeded(hands []Hand, n int) int {
+	needed := 0
+	for _, hand := range hands {
+		needed += n - hand.Cards.Count()
+	}
+	return needed
+}
+
+// Deals every player the cards they're missing out of n from the deck, right into the hands
+func dealPrivateCards(hands []Hand, n int, deck *CardMask, rng *rand.Rand) {
+	for playerIndex, hand := range hands {
+		hands[playerIndex].Cards |= getRandomCardsFromDeck(deck, n-hand.Cards.Count(), rng)
+	}
+}
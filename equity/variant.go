@@ -0,0 +1,234 @@
+package equity
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The poker game being played, deciding how many hole cards a player holds and how a hand is made
+type Variant int
+
+const (
+	// Two hole cards, the best five out of the hole cards and the board
+	Holdem Variant = iota
+	// Four hole cards (PLO4), a hand uses exactly two of them and three board cards
+	Omaha
+	// Five hole cards (PLO5), played like Omaha
+	Omaha5
+	// Hold'em with the 2 to 5 taken out of the deck, the flush beats the full house and A-6-7-8-9 is a straight
+	ShortDeck
+	// Seven card stud, every player gets their own three downcards and four upcards and there's no board
+	Stud
+	// Seven card stud played for the best ace to five low
+	Razz
+	// Deuce to seven lowball single draw, every player keeps some of their five cards and draws the rest once
+	DeuceToSeven
+	// Deuce to seven lowball triple draw, played like single draw with two more draws.
+	// The known cards are the ones kept for the first draw, the cards kept for the later draws follow keepForDraw.
+	TripleDraw
+)
+
+// Names accepted for every variant, the first one is how the variant is written out
+var variantNames = map[Variant][]string{
+	Holdem:       {"holdem", "hold'em", "nlh"},
+	Omaha:        {"omaha", "plo", "plo4"},
+	Omaha5:       {"omaha5", "plo5"},
+	ShortDeck:    {"shortdeck", "short-deck", "6+"},
+	Stud:         {"stud", "7stud", "seven-card-stud"},
+	Razz:         {"razz"},
+	DeuceToSeven: {"27", "2-7", "deuce-to-seven", "27-single-draw"},
+	TripleDraw:   {"27-triple-draw", "2-7-triple-draw", "27td"},
+}
+
+func (v Variant) String() string {
+	if names, ok := variantNames[v]; ok {
+		return names[0]
+	}
+	return fmt.Sprintf("variant %d", int(v))
+}
+
+// Parses the name of a variant like holdem, omaha (plo) or omaha5 (plo5), an empty name is hold'em
+func ParseVariant(text string) (Variant, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "" {
+		return Holdem, nil
+	}
+	for variant, names := range variantNames {
+		for _, name := range names {
+			if name == text {
+				return variant, nil
+			}
+		}
+	}
+	return Holdem, fmt.Errorf("unknown game %q, expected holdem, omaha, omaha5, shortdeck, stud, razz, 27 (single draw) or 27-triple-draw", text)
+}
+
+// Number of cards every player is dealt
+func (v Variant) holeCards() int {
+	switch v {
+	case Omaha:
+		return 4
+	case Omaha5:
+		return 5
+	case Stud, Razz:
+		return studCards
+	case DeuceToSeven, TripleDraw:
+		return drawCards
+	default:
+		return 2
+	}
+}
+
+// Checks the cards known of a player before the game is played out, only the kept cards in a draw game
+func (v Variant) checkKnownCards(hand Hand) error {
+	switch {
+	case v.isStud():
+		return checkStudHand(hand)
+	case v.isDraw():
+		if count := hand.Cards.Count(); count < 1 || count > drawCards {
+			return fmt.Errorf("%w, %v keeps 1 to %v cards, got %v", ErrHandSize, v, drawCards, count)
+		}
+		return nil
+	default:
+		return v.checkHand(hand)
+	}
+}
+
+// Checks the player holds as many hole cards as the variant deals
+func (v Variant) checkHand(hand Hand) error {
+	if count := hand.Cards.Count(); count != v.holeCards() {
+		return fmt.Errorf("%w, %v needs %v, got %v", ErrHandSize, v, v.holeCards(), count)
+	}
+	return nil
+}
+
+// Tells you if the players get cards up and down, as in stud
+func (v Variant) isStud() bool {
+	return v == Stud || v == Razz
+}
+
+// Tells you if the players draw to their own five cards
+func (v Variant) isDraw() bool {
+	return v == DeuceToSeven || v == TripleDraw
+}
+
+// Number of times the players draw in a draw game, 0 in the other games
+func (v Variant) draws() int {
+	switch v {
+	case DeuceToSeven:
+		return 1
+	case TripleDraw:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Tells you if the players play for the best low instead of the best high hand
+func (v Variant) IsLowball() bool {
+	return v == Razz || v.isDraw()
+}
+
+// Number of community cards on a complete board
+func (v Variant) boardCards() int {
+	if v.isStud() || v.isDraw() {
+		return 0
+	}
+	return 5
+}
+
+// Tells you if the variant is played with community cards
+func (v Variant) HasBoard() bool {
+	return v.boardCards() > 0
+}
+
+// Only hold'em hands can be given as a range
+func (v Variant) hasRanges() bool {
+	return v == Holdem || v == ShortDeck
+}
+
+// All the cards the variant is played with
+func (v Variant) deck() CardMask {
+	if v == ShortDeck {
+		return createShortDeck()
+	}
+	return createDeck()
+}
+
+// The order of the hand categories, unless the game says otherwise. Lows aren't ranked by category.
+func (v Variant) ranking() HandRanking {
+	if v.IsLowball() {
+		return nil
+	}
+	if v == ShortDeck {
+		return ShortDeckRanking
+	}
+	return StandardRanking
+}
+
+// Evaluates 5 to 7 cards into a rank in the standard order, or a lowball rank in the lowball variants
+func (v Variant) evaluateCards(cards CardMask) uint32 {
+	switch v {
+	case ShortDeck:
+		return EvaluateShortDeck(cards)
+	case Razz:
+		return EvaluateAceToFive(cards)
+	case DeuceToSeven, TripleDraw:
+		return EvaluateDeuceToSeven(cards)
+	default:
+		return Evaluate(cards)
+	}
+}
+
+// Ranks the best hand a player makes out of their hole cards and the board, in the ranking order of the game
+func (g Game) evaluate(board CardMask, hand CardMask) uint32 {
+	rank := func(cards CardMask) uint32 {
+		return g.Ranking.apply(g.Variant.evaluateCards(cards))
+	}
+	if !g.Ranking.keepsBestCategory() {
+		// The evaluator only looks at the best category in the usual order,
+		// in another order a weaker looking five of the cards might be the best hand
+		rank = func(cards CardMask) uint32 {
+			return bestFiveCards(cards, func(five CardMask) uint32 {
+				return g.Ranking.apply(g.Variant.evaluateCards(five))
+			})
+		}
+	}
+	return g.Variant.bestHand(board, hand, rank)
+}
+
+// Ranks the best eight or better low a player makes out of their hole cards and the board, 0 without a low
+func (g Game) evaluateLow(board CardMask, hand CardMask) uint32 {
+	return g.Variant.bestHand(board, hand, EvaluateLow)
+}
+
+// Ranks the best hand a player can make with the evaluator, following the rules of the variant
+func (v Variant) bestHand(board CardMask, hand CardMask, evaluate func(CardMask) uint32) uint32 {
+	if v != Omaha && v != Omaha5 {
+		return evaluate(board | hand)
+	}
+	// Omaha hands are made of exactly two hole cards and three board cards
+	var bestRank uint32
+	forEachCardCombination(hand, 2, func(holePart CardMask) {
+		forEachCardCombination(board, 3, func(boardPart CardMask) {
+			if rank := evaluate(holePart | boardPart); rank > bestRank {
+				bestRank = rank
+			}
+		})
+	})
+	return bestRank
+}
+
+// Ranks the best five of the cards with the evaluator, or all of them when there are five or fewer
+func bestFiveCards(cards CardMask, evaluate func(CardMask) uint32) uint32 {
+	if cards.Count() <= 5 {
+		return evaluate(cards)
+	}
+	var bestRank uint32
+	forEachCardCombination(cards, 5, func(five CardMask) {
+		if rank := evaluate(five); rank > bestRank {
+			bestRank = rank
+		}
+	})
+	return bestRank
+}
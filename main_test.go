@@ -1,425 +1,105 @@
 package main
 
 import (
-	"fmt"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
 	"testing"
-)
-
-// use go test -cover to get code coverage
-
-func TestCommunityCardStatus(t *testing.T) {
-	cards := CommunityCards{[]Card{
-		Card{1, 'C'},
-		Card{1, 'D'},
-	},
-	}
-	assertPanic(t, func() {
-		cards.status()
-	})
-
-	cards = CommunityCards{[]Card{
-		Card{1, 'C'},
-		Card{1, 'D'},
-		Card{1, 'D'},
-	},
-	}
+	"time"
 
-	if cards.status() != 1 {
-		t.Error("Community Card status should be 1, (flop)")
-	}
-}
-
-func TestIfThereIsAnExtraAceInDeck(t *testing.T) {
-	expected := []int8{
-		1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14,
-	}
-	if !EqualInt8Slice(getAllNumbers(true), expected) {
-		t.Error("Ace is missing from the deck")
-	}
-}
-
-func TestDeckHealth(t *testing.T) {
-	deck := createDeck()
-	healthy := func() {
-		checkDeckHealth(deck)
-	}
-	assertNoPanic(t, healthy)
-
-	if len(deck) != 52 {
-		t.Error("Deck is not healthy")
-	}
-
-	deck[1] = deck[0]
-	healthy = func() {
-		checkDeckHealth(deck)
-	}
-	assertPanic(t, healthy)
-}
-
-func TestNumberCompare(t *testing.T) {
-	outcomes := getOutcomes()
-	p1 := []int8{1,2}
-	p2 := []int8{12,11}
-	outcome := numberCompare(p1,p2)
-	if outcome != outcomes.Win {
-		t.Error("Outcome assessment is wrong")
-	}
-	p1 = []int8{2,3,4}
-	p2 = []int8{10,11,12}
-	outcome = numberCompare(p1,p2)
-	if outcome != outcomes.Lose {
-		t.Error("Outcome assessment is wrong")
-	}
-	p1 = []int8{5,6,7}
-	p2 = []int8{5,6,7}
-	outcome = numberCompare(p1,p2)
-	if outcome != outcomes.Tie {
-		t.Error("Outcome assessment is wrong")
-	}
-	p1 = []int8{}
-	p2 = []int8{}
-	outcome = numberCompare(p1,p2)
-	if outcome != outcomes.Tie {
-		t.Error("Outcome assessment is wrong")
-	}
-}
-
-func TestRegisterPlayerHand(t *testing.T) {
-	combos := getCombinations()
-	//outcomes := getOutcomes()
-	winner := 1
-	candidate := PlayerCombination{
-		CombinationID: combos.Poker,
-		Data:          []int8{1},
-		Kickers:       []Card{
-			{2,'H'},
-		},
-	}
-	existing := PlayerCombination{
-		CombinationID: combos.Poker,
-		Data:          []int8{3},
-		Kickers:       []Card{
-			{2,'H'},
-		},
-	}
-	registerPlayerHand(2, candidate, &existing, &winner)
-	if winner != 2 {
-		t.Error("Player hand registered incorrectly")
-	}
+	"montecarlo/equity"
+)
 
-	winner = 1
-	candidate = PlayerCombination{
-		CombinationID: combos.TwoPairs,
-		Data:          []int8{1,3},
-		Kickers:       []Card{
-			{2,'H'},
-		},
-	}
-	existing = PlayerCombination{
-		CombinationID: combos.Trips,
-		Data:          []int8{2},
-		Kickers:       []Card{
-			{2,'H'},
-		},
+func TestParseFlags(t *testing.T) {
+	args := []string{"--hand", "AhKh", "--hand", "QsQd", "--board", "7s8s2h", "--iterations", "1e6", "--workers", "8"}
+	options, err := parseFlags(args, io.Discard)
+	if err != nil {
+		t.Fatal(err)
 	}
-	registerPlayerHand(2, candidate, &existing, &winner)
-	if winner != 1 {
-		t.Error("Player hand registered incorrectly")
+	if len(options.Hands) != 2 || options.Hands[1] != "QsQd" || options.Board != "7s8s2h" ||
+		options.Iterations != 1000000 || options.Workers != 8 {
+		t.Errorf("Flags parsed incorrectly: %+v", options)
 	}
 
-	winner = 1
-	candidate = PlayerCombination{
-		CombinationID: combos.TwoPairs,
-		Data:          []int8{6,5},
-		Kickers:       []Card{
-			{2,'C'},
-		},
-	}
-	existing = PlayerCombination{
-		CombinationID: combos.TwoPairs,
-		Data:          []int8{6, 5},
-		Kickers:       []Card{
-			{2,'S'},
-		},
+	// A target precision raises the default iteration cap, unless it was given explicitly
+	options, err = parseFlags([]string{"--hand", "AhKh", "--precision", "0.001"}, io.Discard)
+	if err != nil || options.Precision != 0.001 || options.Iterations != defaultPrecisionIterations {
+		t.Errorf("Precision parsed incorrectly: %+v %v", options, err)
 	}
-	registerPlayerHand(2, candidate, &existing, &winner)
-	if winner != -1 {
-		t.Error("Player hand registered incorrectly")
+	options, err = parseFlags([]string{"--hand", "AhKh", "--precision", "0.001", "--iterations", "5000"}, io.Discard)
+	if err != nil || options.Iterations != 5000 {
+		t.Errorf("Iteration cap ignored: %+v %v", options, err)
 	}
-}
 
-func TestAddingCardToDeck(t *testing.T) {
-	deck := createDeck()
-	removeCardFromSlice(&deck, 0)
-	fullDeck := createDeck()
-	if deck[0] != fullDeck[51] || len(deck) != 51 {
-		t.Error("Card was not removed from deck")
+	options, err = parseFlags([]string{"--hand", "AhKh", "--timeout", "1m30s"}, io.Discard)
+	if err != nil || options.Timeout != 90*time.Second {
+		t.Errorf("Timeout parsed incorrectly: %+v %v", options, err)
 	}
-	healthy := func() {
-		checkDeckHealth(deck)
+	options, err = parseFlags([]string{"--hand", "AhKh", "--seed", "0"}, io.Discard)
+	if err != nil || options.Seed != 0 {
+		t.Errorf("Seed parsed incorrectly: %+v %v", options, err)
 	}
-	assertNoPanic(t, healthy)
-}
 
-func TestAddingHandToTable(t *testing.T) {
-	hand := Hand{
-		[2]Card{
-			Card{10, 'C'},
-			Card{1, 'D'},
-		},
+	options, err = parseFlags([]string{"--game", "plo", "--hilo", "--hand", "AhAdKhKd"}, io.Discard)
+	if err != nil || options.Variant != equity.Omaha || !options.HiLo {
+		t.Errorf("Game parsed incorrectly: %+v %v", options, err)
 	}
-	deck := createDeck()
-	var hands []Hand
-	addHandToTable(hand, &deck, &hands)
-	if hands[0] != hand {
-		fmt.Errorf("Hand hasn't been added to the table")
-	}
-	if len(deck) != 50 {
-		fmt.Errorf("Hand hasn't been removed from the deck")
-	}
-}
 
-func TestGettingRandomCard(t *testing.T) {
-	deck := createDeck()
-	crds := getRandomCardsFromDeck(&deck, 2)
-	if len(deck) != 50 {
-		t.Errorf("Did not extract random cards")
+	invalid := [][]string{
+		{"--hand", "AhKh", "--game", "pineapple"},
+		{"--hand", "AhKh", "--ranking", "poker, flush"},
+		{"--board", "7s8s2h"},
+		{"--hand", "AhKh", "--iterations", "0"},
+		{"--hand", "AhKh", "--iterations", "2.5"},
+		{"--hand", "AhKh", "--workers", "0"},
+		{"--hand", "AhKh", "extra"},
+		{"--hand", "AhKh", "--precision", "-0.1"},
+		{"--hand", "AhKh", "--timeout", "-1s"},
 	}
-	for _, c := range deck {
-		if crds[0] == c || crds[1] == c {
-			t.Errorf("Did not extract random cards")
+	for _, args := range invalid {
+		if _, err := parseFlags(args, io.Discard); err == nil {
+			t.Errorf("Flags %v should be rejected", args)
 		}
 	}
-
-	deck2 := createDeck()
-	crds = getRandomCardsFromDeck(&deck2, 0)
-	if len(deck2) != 52 {
-		t.Errorf("Did not extract random cards")
-	}
-	healthy := func() {
-		checkDeckHealth(deck2)
-	}
-	assertNoPanic(t, healthy)
 }
 
-func TestTwoPairCheck(t *testing.T) {
-	cards := []Card{
-		{5, 'H'},
-		{6, 'H'},
-		{1, 'D'},
-		{1, 'H'},
-		{2, 'H'},
-		{7, 'S'},
-		{2, 'S'},
-	}
-	expectedKickers := []Card{
-		{7, 'S'},
+func TestWriteOutput(t *testing.T) {
+	config := equity.Config{Hands: []string{"AhKh", "QQ+"}, Board: "7s8s2h", Dead: "3c", Iterations: 1000}
+	result, err := equity.Simulate(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	pairs, kickers := checkTwoPairs(cards)
-	expectedPairs := []int8{1,2}
-	if !cardSliceContainsSameCards(expectedKickers, kickers) || !EqualInt8Slice(expectedPairs, pairs) {
-		t.Errorf("Two Pairs not found")
+	var output bytes.Buffer
+	if err := writeJSON(&output, result); err != nil {
+		t.Fatal(err)
 	}
-}
-
-func TestCheckMultipleValues(t *testing.T) {
-	cards := []Card{
-		{5, 'H'},
-		{6, 'H'},
-		{7, 'H'},
-		{2, 'H'},
-		{3, 'H'},
-		{1, 'H'},
-		{1, 'S'},
-	}
-
-	result, kickers := checkMultiples(cards, 2, 3)
-	expectedKickers := []Card{
-		{5, 'H'},
-		{6, 'H'},
-		{7, 'H'},
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
 	}
-
-	if result != 1 || len(kickers) != 3 || !cardSliceContainsSameCards(expectedKickers, kickers) {
-		t.Errorf("Pair not found")
-	}
-
-	cards = []Card{
-		{5, 'H'},
-		{7, 'H'},
-		{7, 'S'},
-		{1, 'H'},
-		{1, 'S'},
-	}
-	result, kickers = checkMultiples(cards, 2, 3)
-	expectedKickers = []Card{
-		{5, 'H'},
-		{7, 'H'},
-		{7, 'S'},
-	}
-
-	if result != 1 || len(kickers) != 3 || !cardSliceContainsSameCards(expectedKickers, kickers) {
-		t.Errorf("Pair not found")
-	}
-
-	cards = []Card{
-		{1, 'H'},
-		{7, 'H'},
-		{7, 'S'},
-		{7, 'C'},
-		{1, 'S'},
-	}
-	result, kickers = checkMultiples(cards, 3, 2)
-	expectedKickers = []Card{
-		{1, 'H'},
-		{1, 'S'},
-	}
-
-	if result != 7 || len(kickers) != 2 || !cardSliceContainsSameCards(expectedKickers, kickers) {
-		t.Errorf("Pair not found")
-	}
-}
-
-func TestStraight(t *testing.T) {
-	cards := []Card{
-		{1, 'H'},
-		{2, 'H'},
-		{3, 'S'},
-		{4, 'C'},
-		{5, 'S'},
-		{7, 'S'},
-		{8, 'S'},
-	}
-	straight := checkStraight(cards)
-	if straight != 5 {
-		t.Errorf("Straight not found")
-	}
-
-	cards = []Card{
-		{1, 'H'},
-		{2, 'H'},
-		{3, 'S'},
-		{4, 'C'},
-		{5, 'S'},
-		{6, 'S'},
-		{7, 'S'},
-	}
-	straight = checkStraight(cards)
-	if straight != 7 {
-		t.Errorf("Straight not found")
-	}
-
-	cards = []Card{
-		{1, 'H'},
-		{2, 'H'},
-		{9, 'S'},
-		{10, 'C'},
-		{11, 'S'},
-		{12, 'S'},
-		{13, 'S'},
-	}
-	straight = checkStraight(cards)
-	if straight != 14 {
-		t.Errorf("Straight not found")
-	}
-}
-
-func TestFlush(t *testing.T) {
-	cards := []Card{
-		{1, 'S'},
-		{2, 'H'},
-		{3, 'S'},
-		{4, 'C'},
-		{5, 'S'},
-		{7, 'S'},
-		{8, 'S'},
-	}
-	straight := checkFlush(cards)
-	expected := []int8{1,3,5,7,8}
-	if !EqualInt8Slice(expected, straight) {
-		t.Errorf("Flush not found")
-	}
-
-	cards = []Card{
-		{1, 'C'},
-		{2, 'H'},
-		{3, 'S'},
-		{4, 'C'},
-		{5, 'S'},
-		{7, 'S'},
-		{8, 'S'},
-	}
-	straight = checkFlush(cards)
-	expected = []int8{}
-	if !EqualInt8Slice(expected, straight) {
-		t.Errorf("Flush not found")
-	}
-}
-
-// Asserts that a function throws a panic
-func assertPanic(t *testing.T, f func()) {
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("The code did not panic")
-		}
-	}()
-	f()
-}
-
-// Asserts that a function throws a panic
-func assertNoPanic(t *testing.T, f func()) {
-	defer func() {
-		if r := recover(); r != nil {
-			t.Errorf("The code did panic")
-		}
-	}()
-	f()
-}
-
-// Equal tells whether a and b contain the same elements.
-// A nil argument is equivalent to an empty slice.
-func EqualInt8Slice(a, b []int8) bool {
-	if len(a) != len(b) {
-		return false
-	}
-	for i, v := range a {
-		if v != b[i] {
-			return false
+	for _, key := range []string{"board", "dead", "iterations", "seed", "players", "splits", "elapsed_seconds"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("JSON output is missing %v", key)
 		}
 	}
-	return true
-}
 
-// Equal tells whether a and b contain the same elements.
-// A nil argument is equivalent to an empty slice.
-func EqualCardSlice(a, b []Card) bool {
-	if len(a) != len(b) {
-		return false
-	}
-	for i, v := range a {
-		if v != b[i] {
-			return false
+	output.Reset()
+	writeText(&output, result)
+	for _, text := range []string{"Player ID 0 (AhKh)", "Player ID 1 (QQ+)", "QQ dealt", "Dead cards: 3c", "Split probability"} {
+		if !strings.Contains(output.String(), text) {
+			t.Errorf("Text output is missing %q:\n%v", text, output.String())
 		}
 	}
-	return true
 }
 
-func cardSliceContainsSameCards (a,b []Card) bool {
-	if len(a) != len(b) {
-		return false
+func TestWriteProgress(t *testing.T) {
+	var output bytes.Buffer
+	writeProgress(&output, equity.Progress{Games: 2500, Total: 10000, Rate: 1000, Remaining: 7500 * time.Millisecond,
+		Equities: []float64{0.25, 0.75}})
+	if !strings.HasPrefix(output.String(), "\r2500/10000 games (25.0%), 1000 games/s, 8s left, equity: 25.00% 75.00%") {
+		t.Errorf("Unexpected progress line %q", output.String())
 	}
-	for _, v := range a {
-		found := false
-		for _, v2 := range b {
-			if v == v2 {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return false
-		}
-	}
-	return true
-}
\ No newline at end of file
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"montecarlo/equity"
+)
+
+// Overwrites the progress line with the latest numbers
+func writeProgress(w io.Writer, p equity.Progress) {
+	fmt.Fprintf(w, "\r%v/%v games (%.1f%%), %.0f games/s, %v left, equity:", p.Games, p.Total,
+		float64(p.Games)/float64(p.Total)*100, p.Rate, p.Remaining.Round(time.Second))
+	for _, equity := range p.Equities {
+		fmt.Fprintf(w, " %.2f%%", equity*100)
+	}
+	// Wipe whatever is left of a longer previous line
+	fmt.Fprint(w, "    ")
+}
+
+func writeJSON(w io.Writer, r equity.Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// Prints the results the way they are shown on the terminal
+func writeText(w io.Writer, r equity.Result) {
+	fmt.Fprintln(w, "\n-------\n ")
+	if r.Game != equity.Holdem.String() {
+		fmt.Fprintf(w, "Game: %v\n\n", r.Game)
+	}
+	if r.Exact {
+		fmt.Fprintf(w, "Exact results over all %v boards\n\n", r.Iterations)
+	} else {
+		fmt.Fprintf(w, "Monte Carlo results over %v simulated games (seed %v)\n\n", r.Iterations, r.Seed)
+	}
+	if r.Interrupted {
+		fmt.Fprintf(w, "Stopped early, these results only cover the first %v games\n\n", r.Iterations)
+	}
+	if len(r.Ranking) > 0 {
+		fmt.Fprintf(w, "Hand ranking: %v\n\n", strings.Join(r.Ranking, " > "))
+	}
+	if r.HiLo != nil {
+		fmt.Fprintf(w, "Split pot hi/lo, eight or better. Nobody made a low in %f%% of games\n\n", r.HiLo.NoLow*100)
+	}
+	if r.Dead != "" {
+		fmt.Fprintf(w, "Dead cards: %v\n\n", r.Dead)
+	}
+	// Exact results are known to within any precision, there's nothing to report
+	if r.TargetPrecision > 0 && !r.Exact {
+		if r.Converged {
+			fmt.Fprintf(w, "Every equity is known to within ±%f%%\n\n", r.TargetPrecision*100)
+		} else {
+			fmt.Fprintf(w, "Stopped at the iteration cap before every equity was known to within ±%f%%\n\n", r.TargetPrecision*100)
+		}
+	}
+	if r.SkippedGames > 0 {
+		fmt.Fprintf(w, "%v games were skipped because the ranges couldn't be dealt\n\n", r.SkippedGames)
+	}
+
+	for _, player := range r.Players {
+		fmt.Fprintf(w, "Player ID %v (%v) win: %f%%, tie: %f%%, equity: %f%%", player.Player, player.Hand,
+			player.Win*100, player.Tie*100, player.Equity*100)
+		if !r.Exact {
+			fmt.Fprintf(w, " ± %f%%", player.EquityCI95*100)
+		}
+		fmt.Fprintln(w, " ")
+		if hiLo := player.HiLo; hiLo != nil {
+			fmt.Fprintf(w, "    High equity: %f%%, low equity: %f%%, scoop: %f%%\n", hiLo.HighEquity*100,
+				hiLo.LowEquity*100, hiLo.Scoop*100)
+		}
+		for _, class := range player.SortedClasses() {
+			count := player.SampledClasses[class]
+			fmt.Fprintf(w, "    %v dealt %v times (%f%%)\n", class, count, float64(count)/float64(r.Iterations)*100)
+		}
+		for _, category := range player.Categories {
+			fmt.Fprintf(w, "    %v in %f%% of games, win: %f%%, tie: %f%%\n", category.Category,
+				category.Frequency*100, category.Win*100, category.Tie*100)
+		}
+		for _, street := range player.Streets {
+			if streetEquity := street.Equity; streetEquity != nil {
+				fmt.Fprintf(w, "    Equity on the %v: %f%%, games by equity:", street.Street, streetEquity.Mean*100)
+				for bucket, share := range streetEquity.Distribution {
+					fmt.Fprintf(w, " %v-%v%% %.1f%%", bucket*10, bucket*10+10, share*100)
+				}
+				fmt.Fprintln(w)
+			}
+			leads := street.Leads
+			fmt.Fprintf(w, "    Ahead on the %v: %f%%, ahead but lost: %f%%, behind but won: %f%%\n", street.Street,
+				leads.Ahead*100, leads.AheadButLost*100, leads.BehindButWon*100)
+		}
+	}
+
+	fmt.Fprintf(w, "Split probability: %f%% \n", r.Splits.Probability*100)
+
+	for _, player := range r.Players {
+		outs := player.Outs
+		if outs == nil {
+			continue
+		}
+		fmt.Fprintf(w, "\nPlayer ID %v wins on %v cards on the %v (%f%%)", player.Player, outs.Count, outs.Street, outs.Win*100)
+		if outs.Splits != "" {
+			fmt.Fprintf(w, ", splits on %v", outs.Splits)
+		}
+		fmt.Fprintln(w)
+		for _, group := range outs.Groups {
+			fmt.Fprintf(w, "    %v (%v): %v\n", group.Category, group.Count, group.Cards)
+		}
+	}
+	fmt.Fprintln(w)
+}